@@ -59,7 +59,7 @@
 
 ### Ещё замечание
 
-Для всех примеров я буду использовать простенькую иерархию, состоящую из трех компонентов, *Logger* - это интерфейс, написанный под логгер из стандартной библиотеки, *DBConn* будет изображать соединение с базой данных, а *HTTPServer*, логично, сервер, слушающий определённый порт и производящий некий (фейковый) запрос к базе данных. Соответственно, инициализироваться и запускаться они должны в порядке *Logger*->*DBConn*->*HTTPServer*, а завершаться в обратном порядке.
+Для всех примеров я буду использовать простенькую иерархию, состоящую из трех компонентов, *Logger* - это интерфейс, написанный под логгер из стандартной библиотеки, *DBConn* - пул соединений с базой данных поверх `database/sql` (в примерах он использует фейковый драйвер из `pkg/fakedb`, так что запускать базу не нужно), а *HTTPServer*, логично, сервер, слушающий определённый порт и производящий некий (фейковый) запрос к базе данных. Соответственно, инициализироваться и запускаться они должны в порядке *Logger*->*DBConn*->*HTTPServer*, а завершаться в обратном порядке.
 Для демонстрации работы с блокирующимися и неблокирубщимися компонентами, DBConn не требует постоянной работы (просто необходимо один раз вызвать `DBConn.Connect()`), а `httpServer.Serve`, напротив, блокирует текущий поток исполнения.
 
 ### Reflection based container
@@ -165,7 +165,7 @@
 {{ quote_go_func_body "./pkg/try_selfwritten_lifecycle/main.go" "main" }}
 ```
 И такая идея хороша всем, кроме того, что делает сложным образом то, что можно сделать намного проще, используя нативные средства самого языка.
-(именно поэтому реализации моего пакета `lifecycle` я не стал нигде выкладывать, это не имеет смысла)
+(реализация пакета `lifecycle` для этого примера лежит в `pkg/lifecycle`)
 
 #### Способ финальный
 Существуй мы в мире Java или где-то ещё, то остановились бы на предыдущем варианте, поскольку отслеживать порядок инициализации, запуска и остановки сервисов "руками" звучит, как очень неблагодарная работа без права на ошибку.
@@ -178,16 +178,16 @@
 {{ quote_go_func_body "./pkg/try_manual_pure/simple_example.go" "simpleExampleB" }}
 ```
 
-Правда, остаётся ещё вопрос обработки ошибок, а также возврата первоначальной ошибки (что необязательно, но мне нравится делать именно так). Дело не обойдётся без трех маленьких хелперов:
+Правда, остаётся ещё вопрос обработки ошибок, а также возврата первоначальной ошибки (что необязательно, но мне нравится делать именно так). Дело не обойдётся без трех маленьких хелперов (они лежат в пакете `pkg/manual`, чтобы их можно было переиспользовать, а не копировать):
 * ErrSet - хранилище ошибок для их использования на уровне старта/остановки приложения;
-* Serve - получает контекст и функцию-server, стартует этот server в отдельной горутине и при этом возвращает новый контекст, обернутый в WithCancel, вызываемый при завершении функции-server'а (что позволяет прекратить запуск приложения на середине, если один из предыдущих server'ов завершился);
-* Shutdown - просто вызывает функцию и пишет возможную ошибку в ErrSet, потому что когда приложение уже завершается, нет необходимости как-либо отдельно обрабатывать ошибки завершения компонентов;
+* Serve - получает контекст и функцию-server, стартует этот server в отдельной горутине и при этом возвращает хэндл с методами Done, Err и Stop, а также с новым контекстом, обернутым в WithCancel, вызываемый при завершении функции-server'а (что позволяет прекратить запуск приложения на середине, если один из предыдущих server'ов завершился);
+* Shutdown - просто вызывает функцию с таймаутом и пишет возможную ошибку в ErrSet, потому что когда приложение уже завершается, нет необходимости как-либо отдельно обрабатывать ошибки завершения компонентов;
 
 В итоге, код будет выглядеть так:
 ```go
 {{ quote_file "./pkg/try_manual_pure/main.go" }}
 ```
-В качесте примечания укажу, что в данном примере все компоненты запускаются исключительно в фоновом контексте и напомню, что это лишь демонстрационный образец, который не включает в себя обработку части ошибок и прочих необходимых в продакшене вещей.
+В качесте примечания укажу, что в данном примере все компоненты запускаются исключительно в фоновом контексте (если же серверу нужен контекст, производный от родительского, то для этого есть `manual.ServeContext`) и напомню, что это лишь демонстрационный образец, который не включает в себя обработку части ошибок и прочих необходимых в продакшене вещей.
 
 Что нам даёт такой подход?
 * Добавление компонентов происходит как и раньше, копипастом магических четырех слов New-Serve-defer-Shutdown (будь у нас дженерики, кстати, можно было бы ещё набросать простенький хелпер, чтобы было ещё меньше кода и совсем симпатично);
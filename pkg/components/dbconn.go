@@ -2,40 +2,201 @@ package components
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
 	"sync"
+	"time"
 )
 
-// Fake db connection.
+const (
+	// DefaultConnectBackoff - пауза после первой неудачной попытки подключения, если в DBConfig она не задана.
+	DefaultConnectBackoff = 100 * time.Millisecond
+	// DefaultConnectMaxBackoff - ограничение паузы между попытками подключения, если в DBConfig оно не задано.
+	DefaultConnectMaxBackoff = 5 * time.Second
+)
+
+// DBConfig - настройки подключения к базе данных и пула соединений.
+type DBConfig struct {
+	Driver string // обязательно: имя драйвера database/sql, драйвер должен быть зарегистрирован импортом его пакета
+	DSN    string // обязательно
+
+	MaxOpenConns    int           // 0 - без ограничения
+	MaxIdleConns    int           // 0 - значение по умолчанию database/sql
+	ConnMaxLifetime time.Duration // 0 - соединения не пересоздаются
+
+	// Connect повторяет ping с экспоненциально растущей паузой: база может подняться позже сервиса.
+	ConnectBackoff     time.Duration // пауза после первой неудачной попытки, дальше удваивается; 0 - DefaultConnectBackoff
+	ConnectMaxBackoff  time.Duration // ограничение паузы сверху, с учётом jitter; 0 - DefaultConnectMaxBackoff
+	ConnectJitter      float64       // случайное отклонение паузы, доля от неё, от 0 до 1
+	ConnectMaxDuration time.Duration // сколько всего пытаться подключиться, 0 - пока не отменён контекст
+}
+
+// DBConn - пул соединений с базой данных поверх database/sql.
 type DBConn struct {
 	logger Logger
+	cfg    DBConfig
+	mtx    sync.Mutex
+	db     *sql.DB
 	once   sync.Once
 }
 
-func NewDBConn(logger Logger) *DBConn {
+// NewDBConn создаёт пул с заданными драйвером, DSN и ограничениями, соединения открываются в Connect.
+func NewDBConn(logger Logger, cfg DBConfig) *DBConn {
 	logger.Print("New DBConn")
 	return &DBConn{
 		logger: logger,
+		cfg:    cfg,
 	}
 }
 
+// Connect открывает пул и проверяет соединение ping'ом, повторяя его при неудаче. После отмены ctx пул закрывается.
 func (h *DBConn) Connect(ctx context.Context) error {
 	h.logger.Print("Connecting DBConn")
-	defer h.logger.Print("Connected DBConn")
-	go func() { // эмулируем остановку соединения по отмене контекста
+	if h.cfg.Driver == "" || h.cfg.DSN == "" {
+		return errNotConfigured
+	}
+	db, err := sql.Open(h.cfg.Driver, h.cfg.DSN)
+	if err != nil {
+		return fmt.Errorf("open db: %w", err)
+	}
+	db.SetMaxOpenConns(h.cfg.MaxOpenConns)
+	db.SetMaxIdleConns(h.cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(h.cfg.ConnMaxLifetime)
+	if err := h.ping(ctx, db); err != nil {
+		_ = db.Close()
+		return err
+	}
+
+	h.mtx.Lock()
+	h.db = db
+	h.mtx.Unlock()
+	h.logger.Print("Connected DBConn")
+	go func() { // закрываем пул по отмене контекста
 		<-ctx.Done()
 		_ = h.Stop(context.Background())
 	}()
 	return nil
 }
 
+func (h *DBConn) ping(ctx context.Context, db *sql.DB) error {
+	if h.cfg.ConnectMaxDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.cfg.ConnectMaxDuration)
+		defer cancel()
+	}
+	delay, maxDelay := h.cfg.ConnectBackoff, h.cfg.ConnectMaxBackoff
+	if delay <= 0 {
+		delay = DefaultConnectBackoff
+	}
+	if maxDelay <= 0 {
+		maxDelay = DefaultConnectMaxBackoff
+	}
+	for attempt := 1; ; attempt++ {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("ping db, %d attempts: %w", attempt, err)
+		}
+		wait := jitter(delay, h.cfg.ConnectJitter)
+		if wait > maxDelay {
+			wait = maxDelay
+		}
+		h.logger.Printf("DBConn ping attempt %d failed: %v, retrying in %s", attempt, err, wait)
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("ping db, %d attempts: %v: %w", attempt, err, ctx.Err())
+		}
+		if delay *= 2; delay > maxDelay {
+			delay = maxDelay
+		}
+	}
+}
+
+// jitter случайно отклоняет паузу на долю factor в обе стороны, чтобы экземпляры сервиса не ломились в базу разом.
+func jitter(d time.Duration, factor float64) time.Duration {
+	if factor <= 0 || d <= 0 {
+		return d
+	}
+	return d + time.Duration((rand.Float64()*2-1)*factor*float64(d))
+}
+
+// Stop закрывает пул, дожидаясь завершения текущих запросов. Повторные вызовы ничего не делают.
 func (h *DBConn) Stop(_ context.Context) error {
+	var err error
 	h.once.Do(func() {
 		h.logger.Print("Stop DBConn")
 		defer h.logger.Print("Stopped DBConn")
+		if db := h.pool(); db != nil {
+			err = db.Close()
+		}
 	})
+	return err
+}
+
+// Check проверяет, что соединение живо.
+func (h *DBConn) Check(ctx context.Context) error {
+	db := h.pool()
+	if db == nil {
+		return errNotConnected
+	}
+	if err := db.PingContext(ctx); err != nil {
+		return fmt.Errorf("ping db: %w", err)
+	}
 	return nil
 }
 
-func (h *DBConn) Query(_ string) (string, error) {
-	return "Fake result", nil
+// Query выполняет запрос, возвращённые строки нужно закрыть.
+func (h *DBConn) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	db := h.pool()
+	if db == nil {
+		return nil, errNotConnected
+	}
+	return db.QueryContext(ctx, query, args...)
 }
+
+// QueryStrings выполняет запрос и склеивает все значения всех строк результата через пробел.
+func (h *DBConn) QueryStrings(ctx context.Context, query string, args ...interface{}) (string, error) {
+	rows, err := h.Query(ctx, query, args...)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = rows.Close() }()
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+	var res []string
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return "", err
+		}
+		for _, v := range values {
+			res = append(res, v.String)
+		}
+	}
+	return strings.Join(res, " "), rows.Err()
+}
+
+func (h *DBConn) pool() *sql.DB {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	return h.db
+}
+
+var (
+	errNotConfigured = errors.New("db driver and DSN are required")
+	errNotConnected  = errors.New("db isn't connected")
+)
@@ -0,0 +1,92 @@
+package components_test
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/vivid-money/article-golang-di/pkg/components"
+	"github.com/vivid-money/article-golang-di/pkg/fakedb"
+)
+
+func newDBConn(cfg components.DBConfig) *components.DBConn {
+	return components.NewDBConn(log.New(ioutil.Discard, "", 0), cfg)
+}
+
+func TestDBConnQueryAndStop(t *testing.T) {
+	conn := newDBConn(fakedb.Config())
+	ctx := context.Background()
+	if err := conn.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Check(ctx); err != nil {
+		t.Fatalf("check failed: %v", err)
+	}
+
+	rows, err := conn.Query(ctx, "SELECT * FROM something")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var results []string
+	for rows.Next() {
+		var res string
+		if err := rows.Scan(&res); err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, res)
+	}
+	if err := rows.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0] != "Fake result" {
+		t.Errorf("unexpected rows: %v", results)
+	}
+	if res, err := conn.QueryStrings(ctx, "SELECT 1"); err != nil || res != "Fake result" {
+		t.Errorf("QueryStrings returned %q, %v", res, err)
+	}
+
+	if err := conn.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Stop(ctx); err != nil {
+		t.Errorf("the second stop failed: %v", err)
+	}
+	if _, err := conn.Query(ctx, "SELECT 1"); err == nil {
+		t.Error("the query succeeded after the stop")
+	}
+}
+
+func TestDBConnStopsOnConnectContextCancel(t *testing.T) {
+	conn := newDBConn(fakedb.Config())
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := conn.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	// The pool is closed by a separate goroutine.
+	for deadline := time.Now().Add(time.Second); conn.Check(context.Background()) == nil; {
+		if time.Now().After(deadline) {
+			t.Fatal("the pool isn't closed after the cancel")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestDBConnRequiresDriverAndDSN(t *testing.T) {
+	for _, cfg := range []components.DBConfig{
+		{DSN: "fake"},
+		{Driver: fakedb.DriverName},
+	} {
+		if err := newDBConn(cfg).Connect(context.Background()); err == nil {
+			t.Errorf("connected without the driver or DSN: %+v", cfg)
+		}
+	}
+}
+
+func TestDBConnQueryBeforeConnect(t *testing.T) {
+	if _, err := newDBConn(fakedb.Config()).Query(context.Background(), "SELECT 1"); err == nil {
+		t.Error("the query succeeded before the connect")
+	}
+}
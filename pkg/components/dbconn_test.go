@@ -0,0 +1,97 @@
+package components
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const downDriverName = "components-test-down"
+
+var errDown = errors.New("db is down")
+
+// downDriver is a driver of a db which isn't up yet.
+type downDriver struct{}
+
+func (downDriver) Open(_ string) (driver.Conn, error) {
+	return nil, errDown
+}
+
+func init() {
+	sql.Register(downDriverName, downDriver{})
+}
+
+// syncBuffer is a buffer for a logger used from different goroutines.
+type syncBuffer struct {
+	mtx sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.buf.String()
+}
+
+func TestConnectRetriesWithDefaultBackoff(t *testing.T) {
+	out := &syncBuffer{}
+	conn := NewDBConn(log.New(out, "", 0), DBConfig{Driver: downDriverName, DSN: "down"})
+	ctx, cancel := context.WithTimeout(context.Background(), 250*time.Millisecond)
+	defer cancel()
+
+	err := conn.Connect(ctx)
+	if !errors.Is(err, errDown) && !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// With the default backoff of 100ms doubled after each attempt there is time only for a couple of them.
+	if attempts := strings.Count(out.String(), "ping attempt"); attempts == 0 || attempts > 3 {
+		t.Errorf("%d failed attempts are logged:\n%s", attempts, out)
+	}
+}
+
+func TestConnectMaxBackoffIncludesJitter(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		if d := jitter(time.Second, 0.5); d < 500*time.Millisecond || d > 1500*time.Millisecond {
+			t.Fatalf("jitter is out of range: %s", d)
+		}
+	}
+
+	out := &syncBuffer{}
+	conn := NewDBConn(log.New(out, "", 0), DBConfig{
+		Driver:             downDriverName,
+		DSN:                "down",
+		ConnectBackoff:     20 * time.Millisecond,
+		ConnectMaxBackoff:  20 * time.Millisecond,
+		ConnectJitter:      1,
+		ConnectMaxDuration: 200 * time.Millisecond,
+	})
+	if err := conn.Connect(context.Background()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the max duration to be exceeded, got %v", err)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		idx := strings.Index(line, "retrying in ")
+		if idx < 0 {
+			continue
+		}
+		wait, err := time.ParseDuration(line[idx+len("retrying in "):])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wait > 20*time.Millisecond {
+			t.Errorf("the wait %s exceeds the max backoff", wait)
+		}
+	}
+}
@@ -9,10 +9,87 @@ import (
 
 // Простенький хелпер, чтобы не писать один и тот же код несколько раз.
 func AwaitSignal(ctx context.Context) {
+	NewSignals().Await(ctx)
+}
+
+// Trigger описывает, что прервало ожидание: пришедший сигнал или отмена контекста.
+type Trigger struct {
+	Signal os.Signal // nil, если был отменён контекст
+	Err    error     // ошибка контекста, если был отменён контекст
+}
+
+// ByContext сообщает, что ожидание прервала отмена контекста, а не сигнал.
+func (t Trigger) ByContext() bool {
+	return t.Signal == nil
+}
+
+// Signals - набор сигналов, по которым нужно завершать приложение, и обработчиков остальных сигналов.
+// Настраивать его нужно до вызова Listen или Await.
+type Signals struct {
+	stop     []os.Signal
+	handlers map[os.Signal]func(sig os.Signal)
+}
+
+// NewSignals создаёт набор сигналов завершения, без аргументов это SIGINT и SIGTERM.
+func NewSignals(stop ...os.Signal) *Signals {
+	if len(stop) == 0 {
+		stop = []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+	}
+	return &Signals{
+		stop:     stop,
+		handlers: make(map[os.Signal]func(sig os.Signal)),
+	}
+}
+
+// Handle регистрирует обработчик сигнала, который не должен завершать приложение, например, SIGHUP для перезагрузки
+// конфигурации или SIGUSR1 для вывода диагностики. Обработчик вызывается в горутине, слушающей сигналы, так что
+// следующий сигнал будет обработан только после его завершения.
+func (s *Signals) Handle(sig os.Signal, handler func(sig os.Signal)) *Signals {
+	s.handlers[sig] = handler
+	return s
+}
+
+// Listen отправляет в возвращаемый канал каждый пришедший сигнал завершения, пока не отменён ctx, после чего канал
+// закрывается. Остальные сигналы передаются обработчикам.
+func (s *Signals) Listen(ctx context.Context) <-chan Trigger {
 	sig := make(chan os.Signal, 1)
-	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
-	select {
-	case <-ctx.Done():
-	case <-sig:
+	signals := make([]os.Signal, 0, len(s.stop)+len(s.handlers))
+	signals = append(signals, s.stop...)
+	for handled := range s.handlers {
+		signals = append(signals, handled)
+	}
+	signal.Notify(sig, signals...)
+
+	res := make(chan Trigger)
+	go func() {
+		defer close(res)
+		defer signal.Stop(sig)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case received := <-sig:
+				if handler, ok := s.handlers[received]; ok {
+					handler(received)
+					continue
+				}
+				select {
+				case res <- Trigger{Signal: received}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return res
+}
+
+// Await ждёт первого сигнала завершения или отмены контекста и сообщает, что из этого произошло.
+func (s *Signals) Await(ctx context.Context) Trigger {
+	listenCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if trigger, ok := <-s.Listen(listenCtx); ok {
+		return trigger
 	}
+	return Trigger{Err: ctx.Err()}
 }
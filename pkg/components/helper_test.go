@@ -0,0 +1,95 @@
+package components
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// awaitTrigger waits for a trigger from Listen. Listen is used instead of Await where a signal is sent: the signals
+// are subscribed to when Listen returns, otherwise a signal sent too early would kill the test.
+func awaitTrigger(t *testing.T, triggers <-chan Trigger) Trigger {
+	t.Helper()
+	select {
+	case trigger, ok := <-triggers:
+		if !ok {
+			t.Fatal("triggers are closed")
+		}
+		return trigger
+	case <-time.After(5 * time.Second):
+		t.Fatal("no trigger")
+	}
+	return Trigger{}
+}
+
+func TestSignalsCustomStopSet(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	triggers := NewSignals(syscall.SIGUSR1).Listen(ctx)
+
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatal(err)
+	}
+	trigger := awaitTrigger(t, triggers)
+	if trigger.Signal != syscall.SIGUSR1 || trigger.ByContext() || trigger.Err != nil {
+		t.Errorf("unexpected trigger: %+v", trigger)
+	}
+}
+
+func TestSignalsHandledSignalDoesNotStop(t *testing.T) {
+	handled := make(chan os.Signal, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	triggers := NewSignals(syscall.SIGUSR2).
+		Handle(syscall.SIGUSR1, func(sig os.Signal) { handled <- sig }).
+		Listen(ctx)
+
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case sig := <-handled:
+		if sig != syscall.SIGUSR1 {
+			t.Errorf("handler got %v", sig)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler isn't called")
+	}
+	select {
+	case trigger := <-triggers:
+		t.Fatalf("handled signal has stopped: %+v", trigger)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR2); err != nil {
+		t.Fatal(err)
+	}
+	if trigger := awaitTrigger(t, triggers); trigger.Signal != syscall.SIGUSR2 {
+		t.Errorf("unexpected trigger: %+v", trigger)
+	}
+}
+
+func TestSignalsAwaitByContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	trigger := NewSignals().Await(ctx)
+	if !trigger.ByContext() || trigger.Err != context.Canceled {
+		t.Errorf("unexpected trigger: %+v", trigger)
+	}
+}
+
+func TestSignalsListenClosedByContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	triggers := NewSignals(syscall.SIGUSR1).Listen(ctx)
+	cancel()
+	select {
+	case _, ok := <-triggers:
+		if ok {
+			t.Error("unexpected trigger")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("triggers aren't closed")
+	}
+}
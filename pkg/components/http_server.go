@@ -3,56 +3,97 @@ package components
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
+	"sync"
 )
 
 type HTTPServer struct {
-	httpSrv *http.Server
-	conn    *DBConn
-	logger  Logger
+	name      string
+	httpSrv   *http.Server
+	mux       *http.ServeMux
+	conn      *DBConn
+	logger    Logger
+	ready     chan struct{}
+	readyOnce sync.Once
 }
 
 func NewHTTPServer(logger Logger, conn *DBConn) *HTTPServer {
-	logger.Print("New HTTPServer")
-	mux := http.NewServeMux()
-
-	s := &HTTPServer{
-		conn:   conn,
-		logger: logger,
-	}
-	mux.HandleFunc("/get", func(writer http.ResponseWriter, _ *http.Request) {
-		res, err := conn.Query("SELECT * FROM something")
+	s := newHTTPServer(logger, "HTTPServer", ":3000")
+	s.conn = conn
+	s.mux.HandleFunc("/get", func(writer http.ResponseWriter, req *http.Request) {
+		res, err := conn.QueryStrings(req.Context(), "SELECT * FROM something")
 		if err != nil {
 			writer.WriteHeader(http.StatusInternalServerError)
 			return
 		}
 		_, _ = writer.Write([]byte(res))
 	})
-	s.httpSrv = &http.Server{
-		Addr:    ":3000",
-		Handler: mux,
-	}
 
 	return s
 }
 
+// NewAdminHTTPServer создаёт пустой сервер для служебных обработчиков (пробы, отладка), которые добавляются через
+// Handle. Обычно он слушает отдельный порт, недоступный снаружи.
+func NewAdminHTTPServer(logger Logger, addr string) *HTTPServer {
+	return newHTTPServer(logger, "AdminHTTPServer", addr)
+}
+
+func newHTTPServer(logger Logger, name, addr string) *HTTPServer {
+	logger.Print("New " + name)
+	mux := http.NewServeMux()
+
+	return &HTTPServer{
+		name:   name,
+		mux:    mux,
+		logger: logger,
+		ready:  make(chan struct{}),
+		httpSrv: &http.Server{
+			Addr:    addr,
+			Handler: mux,
+		},
+	}
+}
+
+// Handle добавляет обработчик, вызывать нужно до Serve.
+func (s *HTTPServer) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
 func (s *HTTPServer) Serve(ctx context.Context) error {
-	s.logger.Print("Serving HTTPServer")
-	defer s.logger.Print("Finished serving HTTPServer")
+	s.logger.Print("Serving " + s.name)
+	defer s.logger.Print("Finished serving " + s.name)
 	go func() { // вызываем остановку по отмене контекста, так как net/http не умеет работать с контекстами
 		<-ctx.Done()
 		_ = s.Stop(context.Background())
 	}()
-	if err := s.httpSrv.ListenAndServe(); err != nil {
+	ln, err := net.Listen("tcp", s.httpSrv.Addr)
+	if err != nil {
 		return fmt.Errorf("http listen: %w", err)
 	}
+	s.readyOnce.Do(func() { close(s.ready) }) // порт уже занят, так что можно принимать соединения
+	if err := s.httpSrv.Serve(ln); err != nil {
+		return fmt.Errorf("http serve: %w", err)
+	}
+
+	return nil
+}
+
+// Ready возвращает канал, который закрывается, как только сервер начинает слушать порт.
+func (s *HTTPServer) Ready() <-chan struct{} {
+	return s.ready
+}
 
+// Drain отключает keep-alive перед остановкой, чтобы клиенты переподключались к другим экземплярам сервиса.
+func (s *HTTPServer) Drain(_ context.Context) error {
+	s.logger.Print("Drain " + s.name)
+	s.httpSrv.SetKeepAlivesEnabled(false)
 	return nil
 }
 
 func (s *HTTPServer) Stop(ctx context.Context) error {
-	s.logger.Print("Stop HTTPServer")
-	defer s.logger.Print("Stopped HTTPServer")
+	s.logger.Print("Stop " + s.name)
+	defer s.logger.Print("Stopped " + s.name)
 	if err := s.httpSrv.Shutdown(ctx); err != nil {
 		return fmt.Errorf("http shutdown: %w", err)
 	}
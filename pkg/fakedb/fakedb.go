@@ -0,0 +1,97 @@
+// Package fakedb - драйвер database/sql только для примеров: на любой запрос он отвечает одной строкой
+// "Fake result", так что для запуска примеров не нужна база данных. Драйвер регистрируется импортом пакета.
+package fakedb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/vivid-money/article-golang-di/pkg/components"
+)
+
+// DriverName - имя, под которым драйвер зарегистрирован в database/sql.
+const DriverName = "fakedb"
+
+func init() {
+	sql.Register(DriverName, fakeDriver{})
+}
+
+// Config - настройки components.DBConn для примеров.
+func Config() components.DBConfig {
+	return components.DBConfig{
+		Driver:             DriverName,
+		DSN:                "fake",
+		MaxOpenConns:       10,
+		MaxIdleConns:       2,
+		ConnMaxLifetime:    30 * time.Minute,
+		ConnectJitter:      0.2,
+		ConnectMaxDuration: 30 * time.Second,
+	}
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(_ string) (driver.Conn, error) {
+	return fakeConn{}, nil
+}
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(_ string) (driver.Stmt, error) {
+	return fakeStmt{}, nil
+}
+
+func (fakeConn) Close() error {
+	return nil
+}
+
+func (fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("fake db doesn't support transactions")
+}
+
+func (fakeConn) Ping(_ context.Context) error {
+	return nil
+}
+
+type fakeStmt struct{}
+
+func (fakeStmt) Close() error {
+	return nil
+}
+
+func (fakeStmt) NumInput() int {
+	return -1 // аргументы не проверяются
+}
+
+func (fakeStmt) Exec(_ []driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(0), nil
+}
+
+func (fakeStmt) Query(_ []driver.Value) (driver.Rows, error) {
+	return &fakeRows{}, nil
+}
+
+type fakeRows struct {
+	done bool
+}
+
+func (*fakeRows) Columns() []string {
+	return []string{"result"}
+}
+
+func (*fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = "Fake result"
+	return nil
+}
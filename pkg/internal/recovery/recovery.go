@@ -0,0 +1,37 @@
+// Package recovery converts panics in the goroutines of components to errors, so they don't crash the process
+// before the other components are stopped.
+package recovery
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is a panic recovered in a goroutine of a component along with the stack it has happened at.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v\n\n%s", e.Value, e.Stack)
+}
+
+// Unwrap returns the value of the panic if it's an error, so it can be found by errors.Is and errors.As.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Catch wraps the function so that a panic in it is returned as *PanicError.
+func Catch(fn func(ctx context.Context) error) func(ctx context.Context) error {
+	return func(ctx context.Context) (err error) {
+		defer func() {
+			if v := recover(); v != nil {
+				err = &PanicError{Value: v, Stack: debug.Stack()}
+			}
+		}()
+		return fn(ctx)
+	}
+}
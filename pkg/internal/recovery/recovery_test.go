@@ -0,0 +1,31 @@
+package recovery
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCatch(t *testing.T) {
+	cause := errors.New("boom")
+	err := Catch(func(context.Context) error { panic(cause) })(context.Background())
+
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) {
+		t.Fatalf("expected PanicError, got %v", err)
+	}
+	if !errors.Is(err, cause) {
+		t.Error("the error the panic is called with isn't unwrapped")
+	}
+	if !strings.Contains(err.Error(), "recovery_test.go") {
+		t.Errorf("no stack in the error: %v", err)
+	}
+}
+
+func TestCatchPassesResult(t *testing.T) {
+	cause := errors.New("failed")
+	if err := Catch(func(context.Context) error { return cause })(context.Background()); err != cause {
+		t.Errorf("got %v, want %v", err, cause)
+	}
+}
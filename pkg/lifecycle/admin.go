@@ -0,0 +1,42 @@
+package lifecycle
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Mux is something admin handlers can be registered in, e.g. *http.ServeMux or *components.HTTPServer.
+type Mux interface {
+	Handle(pattern string, handler http.Handler)
+}
+
+// RegisterAdmin registers the admin handlers of the lifecycle:
+//   - /healthz answers 200 while the process is alive, for liveness probes;
+//   - /readyz answers 200 if the application is ready (see Lifecycle.Readiness) and 503 otherwise,
+//     for readiness probes;
+//   - /debug/lifecycle answers with the JSON of the readiness, the health and the states of all components.
+func RegisterAdmin(mux Mux, lc *Lifecycle) {
+	mux.Handle("/healthz", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	mux.Handle("/readyz", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if err := lc.Readiness(); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	mux.Handle("/debug/lifecycle", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		res := struct {
+			Ready      bool             `json:"ready"`
+			Health     Health           `json:"health"`
+			Components []ComponentState `json:"components"`
+		}{
+			Ready:      lc.Readiness() == nil,
+			Health:     lc.Health(),
+			Components: lc.Snapshot(),
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(res)
+	}))
+}
@@ -0,0 +1,73 @@
+package lifecycle
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func get(t *testing.T, h http.Handler, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
+
+func TestAdminHandlers(t *testing.T) {
+	lc := New()
+	lc.AddShutdowner(func(context.Context) error { return nil }, WithName("db"))
+	mux := http.NewServeMux()
+	RegisterAdmin(mux, lc)
+
+	if rec := get(t, mux, "/healthz"); rec.Code != http.StatusOK {
+		t.Errorf("/healthz before the start: %d", rec.Code)
+	}
+	if rec := get(t, mux, "/readyz"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("/readyz before the start: %d, want 503", rec.Code)
+	}
+
+	served := make(chan error, 1)
+	go func() { served <- lc.Serve(context.Background()) }()
+	<-lc.startedCh
+	if rec := get(t, mux, "/readyz"); rec.Code != http.StatusOK {
+		t.Errorf("/readyz of the started application: %d, want 200: %s", rec.Code, rec.Body)
+	}
+
+	rec := get(t, mux, "/debug/lifecycle")
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type %q", ct)
+	}
+	var res struct {
+		Ready      bool
+		Health     Health
+		Components []struct {
+			Name      string
+			State     State
+			Roles     []string
+			StartedAt string `json:"started_at"`
+		}
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		t.Fatalf("can't decode %s: %v", rec.Body, err)
+	}
+	if !res.Ready || res.Health != HealthHealthy || len(res.Components) != 1 {
+		t.Fatalf("unexpected /debug/lifecycle: %s", rec.Body)
+	}
+	if c := res.Components[0]; c.Name != "db" || c.State != StateRunning || c.StartedAt == "" ||
+		len(c.Roles) != 1 || c.Roles[0] != "shutdowner" {
+		t.Errorf("unexpected component: %+v", c)
+	}
+
+	if err := lc.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	<-served
+	if rec := get(t, mux, "/readyz"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("/readyz of the stopped application: %d, want 503", rec.Code)
+	}
+	if rec := get(t, mux, "/healthz"); rec.Code != http.StatusOK {
+		t.Errorf("/healthz of the stopped application: %d", rec.Code)
+	}
+}
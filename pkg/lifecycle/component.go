@@ -0,0 +1,246 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/vivid-money/article-golang-di/pkg/internal/recovery"
+)
+
+type component struct {
+	name   string
+	start  func(ctx context.Context) error
+	serve  func(ctx context.Context) error
+	ready  func() <-chan struct{}
+	stop   func(ctx context.Context) error
+	reload func(ctx context.Context) error
+	check  func(ctx context.Context) error
+	drain  func(ctx context.Context) error
+
+	deps         []string
+	explicitDeps bool
+	startTimeout time.Duration
+	stopTimeout  time.Duration
+
+	restartPolicy     RestartPolicy
+	maxRestarts       int
+	restartBackoff    time.Duration
+	maxRestartBackoff time.Duration
+
+	checkInterval time.Duration
+	checkTimeout  time.Duration
+	nonCritical   bool
+}
+
+type running struct {
+	c             *component
+	cancel        context.CancelFunc
+	ready         <-chan struct{}
+	stopRequested chan struct{} // closed before the component is stopped, so its server isn't restarted
+	exited        chan struct{}
+	exitErr       error         // the error of the server which has exited before becoming ready, set before exited is closed
+	reported      chan struct{} // closed when the result of the start has been sent to observers
+
+	// abandoned is set if the starter hasn't returned in time: exited is closed when it returns with startErr.
+	abandoned bool
+	startErr  error
+}
+
+// start starts the component and notifies observers about it.
+func (l *Lifecycle) start(
+	c *component,
+	deadline <-chan time.Time,
+	interrupt <-chan struct{},
+	failed chan<- error,
+) (*running, error) {
+	begin := time.Now()
+	l.notify(Event{Type: EventStarting, Component: c.name})
+	r, err := l.launch(c, deadline, interrupt, failed)
+	if r != nil {
+		defer close(r.reported)
+	}
+	if err != nil {
+		l.notify(Event{Type: EventStartFailed, Component: c.name, Duration: time.Since(begin), Err: err})
+		return r, err
+	}
+	if r != nil {
+		select {
+		case <-r.ready:
+			l.notify(Event{Type: EventStarted, Component: c.name, Duration: time.Since(begin)})
+		default: // the start was interrupted before the server became ready
+		}
+	}
+	return r, nil
+}
+
+// launch calls the starter of the component and then launches its server in a separate goroutine.
+// The server is considered started once it reports readiness or, if it isn't a Readier, once its goroutine is running.
+// It returns nil running if the component hasn't been started at all, so it mustn't be stopped. If the starter hasn't
+// returned in time, the returned running waits for it, so the component is stopped if the starter succeeds after all.
+// The interrupt channel is closed when the start of the application is aborted.
+func (l *Lifecycle) launch(
+	c *component,
+	deadline <-chan time.Time,
+	interrupt <-chan struct{},
+	failed chan<- error,
+) (*running, error) {
+	var timeout <-chan time.Time
+	if c.startTimeout > 0 {
+		timer := time.NewTimer(c.startTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	r := &running{
+		c:             c,
+		cancel:        cancel,
+		stopRequested: make(chan struct{}),
+		exited:        make(chan struct{}),
+		reported:      make(chan struct{}),
+	}
+	interrupted := false
+	if c.start != nil {
+		started := make(chan error, 1)
+		go func() {
+			started <- recovery.Catch(c.start)(runCtx)
+		}()
+	waitStarted:
+		for {
+			select {
+			case err := <-started:
+				if err != nil {
+					cancel()
+					return nil, fmt.Errorf("can't start %s: %w", c.name, err)
+				}
+				break waitStarted
+			case <-timeout:
+				cancel()
+				return abandon(r, started), fmt.Errorf("%s isn't started after %s: %w", c.name, c.startTimeout, ErrStartTimeout)
+			case <-deadline:
+				cancel()
+				return abandon(r, started), fmt.Errorf("%s isn't started in time: %w", c.name, ErrStartTimeout)
+			case <-interrupt:
+				// The starter is still waited for: if it succeeds, the component has to be rolled back.
+				interrupted = true
+				interrupt = nil
+			}
+		}
+	}
+	if c.serve == nil || interrupted {
+		close(r.exited)
+		r.ready = r.exited
+		return r, nil
+	}
+
+	launched := make(chan struct{})
+	r.ready = launched
+	if c.ready != nil {
+		r.ready = c.ready()
+	}
+	go func() {
+		defer close(r.exited)
+		close(launched)
+		if err := l.serveWithRestarts(runCtx, r); err != nil {
+			select {
+			case <-r.ready:
+				failed <- err
+			default:
+				r.exitErr = err // it's a start failure reported by launch
+			}
+		}
+	}()
+
+	// Dependent components are started only after the server has reported that it is ready.
+	select {
+	case <-r.ready:
+	case <-r.exited:
+		select {
+		case <-r.ready: // it has become ready and then failed, the failure is reported by the server's goroutine
+		default:
+			err := r.exitErr
+			if err == nil {
+				err = errExitedWithoutError
+			}
+			return r, fmt.Errorf("%s exited before becoming ready: %w", c.name, err)
+		}
+	case <-timeout:
+		return r, fmt.Errorf("%s isn't ready after %s: %w", c.name, c.startTimeout, ErrStartTimeout)
+	case <-deadline:
+		return r, fmt.Errorf("%s isn't ready in time: %w", c.name, ErrStartTimeout)
+	case <-interrupt:
+	}
+	return r, nil
+}
+
+// abandon makes the running of the component whose starter hasn't returned in time. The starter may still succeed,
+// so the component is rolled back like a started one: its shutdowner is called if the starter returns nil.
+func abandon(r *running, started <-chan error) *running {
+	r.abandoned = true
+	r.ready = r.exited
+	go func() {
+		r.startErr = <-started
+		close(r.exited)
+	}()
+	return r
+}
+
+// stop stops the component and notifies observers about it.
+func (l *Lifecycle) stop(ctx context.Context, r *running) error {
+	begin := time.Now()
+	l.notify(Event{Type: EventStopping, Component: r.c.name})
+	err := l.shutdown(ctx, r)
+	l.notify(Event{Type: EventStopped, Component: r.c.name, Duration: time.Since(begin), Err: err})
+	return err
+}
+
+// shutdown calls the shutdowner of the component, cancels its context and waits until its server exits.
+func (l *Lifecycle) shutdown(ctx context.Context, r *running) error {
+	if r.c.stopTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.c.stopTimeout)
+		defer cancel()
+	}
+
+	close(r.stopRequested)
+	stopped := make(chan error, 1)
+	go func() {
+		if r.abandoned {
+			<-r.exited
+			if r.startErr != nil {
+				stopped <- nil // it hasn't been started, there is nothing to stop
+				return
+			}
+		}
+		var err error
+		if r.c.stop != nil {
+			err = recovery.Catch(r.c.stop)(ctx)
+		}
+		r.cancel()
+		<-r.exited
+		stopped <- err
+	}()
+
+	select {
+	case err := <-stopped:
+		if err != nil {
+			return fmt.Errorf("can't stop %s: %w", r.c.name, err)
+		}
+		return nil
+	case <-ctx.Done():
+		r.cancel()
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("%s isn't stopped in time: %w", r.c.name, ErrStopTimeout)
+		}
+		return fmt.Errorf("can't stop %s: %w", r.c.name, ctx.Err())
+	}
+}
+
+// isExpectedStopErr reports whether the error is the usual result of a server stopped by the lifecycle. It's checked
+// only after the stop has been requested: a server returning such an error by itself has failed.
+func isExpectedStopErr(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, http.ErrServerClosed)
+}
@@ -0,0 +1,251 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/multierr"
+)
+
+// recorder keeps the events of a lifecycle as "Type component" strings.
+type recorder struct {
+	mtx    sync.Mutex
+	events []string
+}
+
+func (r *recorder) OnEvent(e Event) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.events = append(r.events, e.Type.String()+" "+e.Component)
+}
+
+func (r *recorder) has(event string) bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	for _, e := range r.events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// neverReady is a server which fails before it becomes ready, like an http server which can't listen its port.
+type neverReady struct {
+	err error
+}
+
+func (s neverReady) Serve(_ context.Context) error { return s.err }
+
+func (s neverReady) Ready() <-chan struct{} { return make(chan struct{}) }
+
+func TestServeFailsIfServerExitsBeforeReady(t *testing.T) {
+	rec := &recorder{}
+	lc := New(WithObserver(rec))
+	listenErr := errors.New("address already in use")
+	if err := lc.Add(neverReady{err: listenErr}, WithName("server")); err != nil {
+		t.Fatal(err)
+	}
+	lc.AddStarter(func(context.Context) error { return nil }, WithName("dependent"))
+
+	err := lc.Serve(context.Background())
+	var startErr *StartError
+	if !errors.As(err, &startErr) {
+		t.Fatalf("expected StartError, got %v", err)
+	}
+	if !errors.Is(err, listenErr) || !strings.Contains(err.Error(), "exited before becoming ready") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !rec.has("StartFailed server") {
+		t.Errorf("StartFailed isn't sent: %v", rec.events)
+	}
+	if rec.has("Starting dependent") {
+		t.Errorf("dependent is started on top of the failed server: %v", rec.events)
+	}
+}
+
+func TestServerPanicStopsApplication(t *testing.T) {
+	stopped := make(chan struct{})
+	lc := New()
+	lc.AddShutdowner(func(context.Context) error {
+		close(stopped)
+		return nil
+	}, WithName("db"))
+	lc.AddServer(func(context.Context) error {
+		panic("boom")
+	}, WithName("server"))
+
+	err := lc.Serve(context.Background())
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) || panicErr.Value != "boom" || len(panicErr.Stack) == 0 {
+		t.Fatalf("expected PanicError with a stack, got %v", err)
+	}
+	select {
+	case <-stopped:
+	default:
+		t.Error("the other components aren't stopped")
+	}
+}
+
+func TestServerExitStopsApplication(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{name: "without error"},
+		{name: "canceled", err: fmt.Errorf("worker: %w", context.Canceled)},
+		{name: "server closed", err: http.ErrServerClosed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lc := New()
+			lc.AddServer(func(context.Context) error {
+				time.Sleep(50 * time.Millisecond)
+				return tt.err
+			}, WithName("worker"))
+
+			served := make(chan error, 1)
+			go func() { served <- lc.Serve(context.Background()) }()
+			var err error
+			select {
+			case err = <-served:
+			case <-time.After(5 * time.Second):
+				_ = lc.Stop(context.Background())
+				t.Fatal("Serve isn't finished after the server has exited")
+			}
+			var serverErr *ServerError
+			if !errors.As(err, &serverErr) || serverErr.Component != "worker" {
+				t.Fatalf("expected ServerError of the worker, got %v", err)
+			}
+			if tt.err == nil && !strings.Contains(err.Error(), "exited without an error") {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestStoppedServerErrorIsExpected(t *testing.T) {
+	lc := New()
+	lc.AddServer(func(ctx context.Context) error {
+		<-ctx.Done()
+		return fmt.Errorf("worker: %w", ctx.Err())
+	}, WithName("worker"))
+	lc.AddServer(func(ctx context.Context) error {
+		<-ctx.Done()
+		return http.ErrServerClosed
+	}, WithName("http"))
+
+	if err := serveAndStop(t, lc); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// panicking panics in all of its roles but Serve.
+type panicking struct{}
+
+func (panicking) Serve(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+func (panicking) Stop(_ context.Context) error { panic("stop") }
+
+func (panicking) Check(_ context.Context) error { panic("check") }
+
+func (panicking) Drain(_ context.Context) error { panic("drain") }
+
+func TestPanicsInStopCheckAndDrainAreErrors(t *testing.T) {
+	lc := New()
+	if err := lc.Add(panicking{}, WithName("server")); err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan error, 1)
+	go func() { served <- lc.Serve(context.Background()) }()
+	<-lc.startedCh
+	for lc.Health() != HealthUnhealthy {
+		time.Sleep(time.Millisecond) // the first check is done right after the start
+	}
+	var panicErr *PanicError
+	if st := lc.Snapshot()[0]; !errors.As(st.HealthErr, &panicErr) || panicErr.Value != "check" {
+		t.Errorf("unexpected health error: %v", st.HealthErr)
+	}
+
+	_ = lc.Stop(context.Background())
+	err := <-served
+	var values []interface{}
+	for _, e := range multierr.Errors(err) {
+		if errors.As(e, &panicErr) {
+			values = append(values, panicErr.Value)
+		}
+	}
+	if fmt.Sprint(values) != "[drain stop]" {
+		t.Errorf("expected the panics of Drain and Stop, got %v", err)
+	}
+}
+
+// delayedReady is a server which becomes ready only when it's allowed to.
+type delayedReady struct {
+	o     *order
+	ready chan struct{}
+}
+
+func (s delayedReady) Serve(ctx context.Context) error {
+	s.o.add("serve")
+	<-ctx.Done()
+	return nil
+}
+
+func (s delayedReady) Ready() <-chan struct{} { return s.ready }
+
+func TestComponentsStartOneByOne(t *testing.T) {
+	var o order
+	srv := delayedReady{o: &o, ready: make(chan struct{})}
+	lc := New()
+	lc.AddStarter(func(context.Context) error {
+		time.Sleep(50 * time.Millisecond)
+		o.add("db started")
+		return nil
+	}, WithName("db"))
+	if err := lc.Add(srv, WithName("http")); err != nil {
+		t.Fatal(err)
+	}
+	lc.AddStarter(func(context.Context) error {
+		o.add("dependent started")
+		return nil
+	}, WithName("dependent"))
+
+	served := make(chan error, 1)
+	go func() { served <- lc.Serve(context.Background()) }()
+	for o.index("serve") < 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if o.index("dependent started") >= 0 {
+		t.Fatalf("dependent is started before the server is ready: %v", o.calls)
+	}
+	if st := lc.Snapshot()[1]; st.State != StateStarting {
+		t.Errorf("server which isn't ready is %s", st.State)
+	}
+	o.add("ready")
+	close(srv.ready)
+	<-lc.startedCh
+
+	want := []string{"db started", "serve", "ready", "dependent started"}
+	if !reflect.DeepEqual(o.calls, want) {
+		t.Errorf("calls %v, want %v", o.calls, want)
+	}
+	if err := lc.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	<-served
+}
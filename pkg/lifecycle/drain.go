@@ -0,0 +1,64 @@
+package lifecycle
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/multierr"
+
+	"github.com/vivid-money/article-golang-di/pkg/internal/recovery"
+)
+
+// WithDrainPeriod sets how long the lifecycle waits after it has turned readiness off and notified the drainers
+// before it starts stopping the components, so load balancers have time to notice. The period isn't counted in
+// the total stop timeout.
+func WithDrainPeriod(d time.Duration) Option {
+	return func(l *Lifecycle) {
+		l.drainPeriod = d
+	}
+}
+
+// drain turns readiness off, notifies all started drainers and waits for the drain period.
+func (l *Lifecycle) drain(nodes []*node) error {
+	l.mtx.Lock()
+	l.draining = true
+	l.mtx.Unlock()
+	ctx := l.stopContext()
+
+	var (
+		mtx sync.Mutex
+		err error
+		wg  sync.WaitGroup
+	)
+	for _, n := range nodes {
+		if n.r == nil || n.c.drain == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(c *component) {
+			defer wg.Done()
+			begin := time.Now()
+			l.notify(Event{Type: EventDraining, Component: c.name})
+			drainErr := recovery.Catch(c.drain)(ctx)
+			if drainErr != nil {
+				drainErr = fmt.Errorf("can't drain %s: %w", c.name, drainErr)
+				mtx.Lock()
+				err = multierr.Append(err, drainErr)
+				mtx.Unlock()
+			}
+			l.notify(Event{Type: EventDrained, Component: c.name, Duration: time.Since(begin), Err: drainErr})
+		}(n.c)
+	}
+	wg.Wait()
+
+	if l.drainPeriod > 0 {
+		timer := time.NewTimer(l.drainPeriod)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+	}
+	return err
+}
@@ -0,0 +1,93 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// drainer records its drain and stop with the readiness of the lifecycle at the drain.
+type drainer struct {
+	lc *Lifecycle
+	o  *order
+
+	mtx        sync.Mutex
+	drainedAt  time.Time
+	stoppedAt  time.Time
+	readiness  error
+	drainCalls int
+}
+
+func (d *drainer) Connect(_ context.Context) error { return nil }
+
+func (d *drainer) Drain(_ context.Context) error {
+	readiness := d.lc.Readiness()
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.drainCalls++
+	d.drainedAt = time.Now()
+	d.readiness = readiness
+	d.o.add("drain")
+	return nil
+}
+
+func (d *drainer) Stop(_ context.Context) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.stoppedAt = time.Now()
+	d.o.add("stop drainer")
+	return nil
+}
+
+func TestDrainBeforeStop(t *testing.T) {
+	var o order
+	lc := New(WithDrainPeriod(100 * time.Millisecond))
+	d := &drainer{lc: lc, o: &o}
+	lc.AddShutdowner(func(context.Context) error {
+		o.add("stop db")
+		return nil
+	}, WithName("db"))
+	if err := lc.Add(d, WithName("http")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := serveAndStop(t, lc); err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(d.readiness, ErrNotReady) {
+		t.Errorf("readiness at the drain: %v, want ErrNotReady", d.readiness)
+	}
+	if drain := o.index("drain"); drain != 0 || len(o.calls) != 3 {
+		t.Errorf("drainer isn't called before all stops: %v", o.calls)
+	}
+	if waited := d.stoppedAt.Sub(d.drainedAt); waited < 100*time.Millisecond {
+		t.Errorf("stopped %s after the drain, want at least the drain period", waited)
+	}
+}
+
+func TestNoDrainAfterStartFailure(t *testing.T) {
+	var o order
+	lc := New(WithDrainPeriod(time.Minute))
+	d := &drainer{lc: lc, o: &o}
+	if err := lc.Add(d, WithName("http")); err != nil {
+		t.Fatal(err)
+	}
+	lc.AddStarter(func(context.Context) error { return errors.New("connection refused") }, WithName("db"))
+
+	begin := time.Now()
+	var startErr *StartError
+	if err := lc.Serve(context.Background()); !errors.As(err, &startErr) {
+		t.Fatalf("expected StartError, got %v", err)
+	}
+	if d.drainCalls != 0 {
+		t.Error("drainer is called after the start failure")
+	}
+	if o.index("stop drainer") < 0 {
+		t.Error("started drainer isn't rolled back")
+	}
+	if time.Since(begin) > 10*time.Second {
+		t.Error("the drain period is waited after the start failure")
+	}
+}
@@ -0,0 +1,53 @@
+package lifecycle
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vivid-money/article-golang-di/pkg/internal/recovery"
+)
+
+var (
+	// ErrAlreadyServing is returned by Serve if the lifecycle was already started.
+	ErrAlreadyServing = errors.New("lifecycle is already serving")
+	// ErrNoRoles is returned by Add if a component implements none of the lifecycle interfaces.
+	ErrNoRoles = errors.New("component implements none of the lifecycle interfaces")
+	// ErrStartTimeout is returned by Serve if a component isn't started or ready in time.
+	ErrStartTimeout = errors.New("start timeout")
+	// ErrStopTimeout is returned by Serve if a component isn't stopped in time.
+	ErrStopTimeout = errors.New("stop timeout")
+	// ErrNotRunning is returned by Reload if the application isn't started yet or is already stopping.
+	ErrNotRunning = errors.New("lifecycle isn't running")
+	// ErrNotReady is returned by Readiness if the application isn't ready to serve requests.
+	ErrNotReady = errors.New("not ready")
+)
+
+// PanicError is a panic recovered in a starter or a server: it fails the component like an ordinary error.
+type PanicError = recovery.PanicError
+
+// StartError is returned by Serve if the application hasn't been started.
+type StartError struct {
+	Err error
+}
+
+func (e *StartError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *StartError) Unwrap() error {
+	return e.Err
+}
+
+// ServerError is returned by Serve if a server has failed.
+type ServerError struct {
+	Component string
+	Err       error
+}
+
+func (e *ServerError) Error() string {
+	return fmt.Sprintf("%s failed: %v", e.Component, e.Err)
+}
+
+func (e *ServerError) Unwrap() error {
+	return e.Err
+}
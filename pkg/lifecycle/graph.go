@@ -0,0 +1,213 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/multierr"
+)
+
+// node is a component inside the dependency graph built by Serve.
+type node struct {
+	c          *component
+	deps       []*node
+	dependents []*node
+
+	r       *running
+	started chan struct{} // closed when the component is started or when it's known it won't be
+	stopped chan struct{} // closed when the component is stopped or when it's known it doesn't have to be
+}
+
+func buildGraph(components []*component) ([]*node, error) {
+	byName := make(map[string]*node, len(components))
+	nodes := make([]*node, 0, len(components))
+	for _, c := range components {
+		n := &node{c: c, started: make(chan struct{}), stopped: make(chan struct{})}
+		byName[c.name] = n
+		nodes = append(nodes, n)
+	}
+	for _, n := range nodes {
+		for _, name := range n.c.deps {
+			dep, ok := byName[name]
+			if !ok {
+				return nil, fmt.Errorf("%s depends on unknown component %q", n.c.name, name)
+			}
+			n.deps = append(n.deps, dep)
+			dep.dependents = append(dep.dependents, n)
+		}
+	}
+	return nodes, nil
+}
+
+// findCycle returns a path like "a -> b -> a" if the component c is reachable from itself by its dependencies.
+// Dependencies which aren't registered yet are skipped, they are checked by Serve.
+func findCycle(components []*component, c *component) string {
+	byName := make(map[string]*component, len(components)+1)
+	for _, other := range components {
+		byName[other.name] = other
+	}
+	byName[c.name] = c
+
+	visited := make(map[string]bool)
+	var path []string
+	var walk func(cur *component) bool
+	walk = func(cur *component) bool {
+		path = append(path, cur.name)
+		for _, name := range cur.deps {
+			if name == c.name {
+				path = append(path, name)
+				return true
+			}
+			dep, ok := byName[name]
+			if !ok || visited[name] {
+				continue
+			}
+			visited[name] = true
+			if walk(dep) {
+				return true
+			}
+		}
+		path = path[:len(path)-1]
+		return false
+	}
+	if walk(c) {
+		return strings.Join(path, " -> ")
+	}
+	return ""
+}
+
+// startAll starts every component as soon as all of its dependencies are started, so independent branches are
+// started concurrently. The first error or interruption prevents all components which aren't started yet from
+// starting.
+func (l *Lifecycle) startAll(ctx context.Context, nodes []*node, failed chan error) error {
+	var deadline <-chan time.Time
+	if l.startTimeout > 0 {
+		timer := time.NewTimer(l.startTimeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	var (
+		mtx       sync.Mutex
+		err       error
+		abortOnce sync.Once
+		abort     = make(chan struct{})
+	)
+	interrupt := func(e error) {
+		mtx.Lock()
+		if err == nil {
+			err = e
+		}
+		mtx.Unlock()
+		abortOnce.Do(func() { close(abort) })
+	}
+
+	var wg sync.WaitGroup
+	for _, n := range nodes {
+		wg.Add(1)
+		go func(n *node) {
+			defer wg.Done()
+			defer close(n.started)
+			for _, dep := range n.deps {
+				select {
+				case <-dep.started:
+				case <-abort:
+					return
+				}
+				if dep.r == nil {
+					return
+				}
+			}
+			select {
+			case <-abort:
+				return
+			default:
+			}
+
+			r, startErr := l.start(n.c, deadline, abort, failed)
+			n.r = r
+			if startErr != nil {
+				interrupt(startErr)
+			}
+		}(n)
+	}
+
+	allStarted := make(chan struct{})
+	watched := make(chan struct{})
+	go func() {
+		defer close(watched)
+		select {
+		case <-ctx.Done():
+		case <-l.stopCh:
+		case e := <-failed:
+			interrupt(e)
+		case <-allStarted:
+			return
+		}
+		interrupt(nil)
+	}()
+	wg.Wait()
+	close(allStarted)
+	<-watched
+
+	return err
+}
+
+// stopContext returns the context passed to Stop or the background one if the lifecycle is stopped otherwise.
+func (l *Lifecycle) stopContext() context.Context {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if l.stopCtx == nil {
+		return context.Background()
+	}
+	return l.stopCtx
+}
+
+// stopAll stops every started component after all of its dependents are stopped, so independent branches are
+// stopped concurrently.
+func (l *Lifecycle) stopAll(nodes []*node) error {
+	ctx := l.stopContext()
+
+	if l.stopTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, l.stopTimeout)
+		defer cancel()
+		// The components are given up at the deadline of ctx, so normally stopAll returns right after it with
+		// ErrStopTimeout. It escalates only if even that doesn't happen in the grace period.
+		budget := l.stopTimeout + l.escalationGrace
+		watchdog := time.AfterFunc(budget, func() {
+			l.escalate(fmt.Errorf("lifecycle isn't stopped after %s: %w", budget, ErrStopTimeout))
+		})
+		defer watchdog.Stop()
+	}
+
+	var (
+		mtx sync.Mutex
+		err error
+		wg  sync.WaitGroup
+	)
+	for _, n := range nodes {
+		wg.Add(1)
+		go func(n *node) {
+			defer wg.Done()
+			defer close(n.stopped)
+			for _, dependent := range n.dependents {
+				<-dependent.stopped
+			}
+			if n.r == nil {
+				return
+			}
+			if stopErr := l.stop(ctx, n.r); stopErr != nil {
+				mtx.Lock()
+				err = multierr.Append(err, stopErr)
+				mtx.Unlock()
+			}
+		}(n)
+	}
+	wg.Wait()
+
+	return err
+}
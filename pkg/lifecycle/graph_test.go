@@ -0,0 +1,285 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/multierr"
+)
+
+// serveAndStop starts the lifecycle, stops it once it's started and returns the result of Serve.
+func serveAndStop(t *testing.T, lc *Lifecycle) error {
+	t.Helper()
+	served := make(chan error, 1)
+	go func() { served <- lc.Serve(context.Background()) }()
+	select {
+	case <-lc.startedCh:
+	case err := <-served:
+		t.Fatalf("can't start: %v", err)
+	}
+	_ = lc.Stop(context.Background())
+	return <-served
+}
+
+func TestStopTimeoutDoesNotEscalate(t *testing.T) {
+	var escalated int32
+	lc := New(
+		WithTotalStopTimeout(50*time.Millisecond),
+		WithStopEscalation(func(error) { atomic.AddInt32(&escalated, 1) }),
+	)
+	lc.AddShutdowner(func(context.Context) error {
+		time.Sleep(300 * time.Millisecond) // ignores ctx
+		return nil
+	})
+	err := serveAndStop(t, lc)
+	if !errors.Is(err, ErrStopTimeout) {
+		t.Fatalf("expected ErrStopTimeout, got %v", err)
+	}
+	if atomic.LoadInt32(&escalated) != 0 {
+		t.Error("the stop which has returned in time is escalated")
+	}
+}
+
+func TestStuckStopEscalates(t *testing.T) {
+	escalated := make(chan error, 1)
+	release := make(chan struct{})
+	lc := New(
+		WithTotalStopTimeout(50*time.Millisecond),
+		WithStopEscalation(func(err error) {
+			escalated <- err
+			close(release)
+		}),
+		WithObserver(ObserverFunc(func(e Event) {
+			if e.Type == EventStopping {
+				<-release // the stop is stuck outside of the components
+			}
+		})),
+	)
+	lc.escalationGrace = 50 * time.Millisecond
+	lc.AddShutdowner(func(context.Context) error { return nil })
+	_ = serveAndStop(t, lc)
+	select {
+	case err := <-escalated:
+		if !errors.Is(err, ErrStopTimeout) {
+			t.Errorf("unexpected escalation error: %v", err)
+		}
+	default:
+		t.Error("the stuck stop isn't escalated")
+	}
+}
+
+// order records the calls of the components.
+type order struct {
+	mtx   sync.Mutex
+	calls []string
+}
+
+func (o *order) add(call string) {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	o.calls = append(o.calls, call)
+}
+
+func (o *order) index(call string) int {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	for i, c := range o.calls {
+		if c == call {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestIndependentBranchesStartConcurrently(t *testing.T) {
+	var (
+		o       order
+		barrier sync.WaitGroup
+	)
+	barrier.Add(2)
+	// Each branch is started only when the other one is starting too, so a sequential start would time out.
+	branch := func(name string) func(context.Context) error {
+		return func(ctx context.Context) error {
+			barrier.Done()
+			barrier.Wait()
+			o.add("start " + name)
+			return nil
+		}
+	}
+	lc := New(WithTotalStartTimeout(5 * time.Second))
+	lc.AddStarter(branch("a"), WithName("a"), DependsOn())
+	lc.AddStarter(branch("b"), WithName("b"), DependsOn())
+	lc.AddStarter(func(context.Context) error {
+		o.add("start c")
+		return nil
+	}, WithName("c"), DependsOn("a", "b"))
+
+	if err := serveAndStop(t, lc); err != nil {
+		t.Fatal(err)
+	}
+	if c := o.index("start c"); c < o.index("start a") || c < o.index("start b") {
+		t.Errorf("c is started before its dependencies: %v", o.calls)
+	}
+}
+
+func TestDependentsStopBeforeDependencies(t *testing.T) {
+	var o order
+	stopper := func(name string) func(context.Context) error {
+		return func(context.Context) error {
+			o.add("stop " + name)
+			return nil
+		}
+	}
+	lc := New()
+	lc.AddShutdowner(stopper("db"), WithName("db"), DependsOn())
+	lc.AddShutdowner(stopper("cache"), WithName("cache"), DependsOn())
+	lc.AddShutdowner(stopper("repo"), WithName("repo"), DependsOn("db", "cache"))
+	lc.AddShutdowner(stopper("api"), WithName("api"), DependsOn("repo"))
+	lc.AddShutdowner(stopper("admin"), WithName("admin"), DependsOn("db"))
+
+	if err := serveAndStop(t, lc); err != nil {
+		t.Fatal(err)
+	}
+	before := [][2]string{{"api", "repo"}, {"repo", "db"}, {"repo", "cache"}, {"admin", "db"}}
+	for _, pair := range before {
+		if o.index("stop "+pair[0]) > o.index("stop "+pair[1]) {
+			t.Errorf("%s is stopped after %s: %v", pair[0], pair[1], o.calls)
+		}
+	}
+	if len(o.calls) != 5 {
+		t.Errorf("unexpected stops: %v", o.calls)
+	}
+}
+
+func TestDependencyCycle(t *testing.T) {
+	lc := New()
+	nop := func(context.Context) error { return nil }
+	lc.AddStarter(nop, WithName("a"), DependsOn("b"))
+	lc.AddStarter(nop, WithName("b"), DependsOn("a"))
+
+	err := lc.Serve(context.Background())
+	var startErr *StartError
+	if !errors.As(err, &startErr) {
+		t.Fatalf("expected StartError, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "dependency cycle b -> a -> b") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestUnknownDependency(t *testing.T) {
+	lc := New()
+	lc.AddStarter(func(context.Context) error { return nil }, WithName("a"), DependsOn("missing"))
+
+	err := lc.Serve(context.Background())
+	var startErr *StartError
+	if !errors.As(err, &startErr) || !strings.Contains(err.Error(), `unknown component "missing"`) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// recordingComponent records its start and stop and fails them with the given errors. Its starter ignores ctx
+// and returns after the delay.
+type recordingComponent struct {
+	name              string
+	o                 *order
+	startErr, stopErr error
+	delay             time.Duration
+}
+
+func (c recordingComponent) Start(_ context.Context) error {
+	time.Sleep(c.delay)
+	c.o.add("start " + c.name)
+	return c.startErr
+}
+
+func (c recordingComponent) Stop(_ context.Context) error {
+	c.o.add("stop " + c.name)
+	return c.stopErr
+}
+
+func TestRollbackStopsOnlyStartedComponents(t *testing.T) {
+	var o order
+	connErr := errors.New("connection refused")
+	closeErr := errors.New("close failed")
+	lc := New()
+	add := func(c recordingComponent, deps ...string) {
+		c.o = &o
+		if err := lc.Add(c, WithName(c.name), DependsOn(deps...)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	add(recordingComponent{name: "db", stopErr: closeErr})
+	add(recordingComponent{name: "cache"})
+	add(recordingComponent{name: "repo"}, "db")
+	add(recordingComponent{name: "queue", startErr: connErr}, "repo", "cache")
+	add(recordingComponent{name: "api"}, "queue")
+
+	err := lc.Serve(context.Background())
+	var startErr *StartError
+	if !errors.As(err, &startErr) || !errors.Is(startErr, connErr) {
+		t.Fatalf("expected StartError caused by the queue, got %v", err)
+	}
+	errs := multierr.Errors(err)
+	if len(errs) != 2 || !errors.Is(errs[1], closeErr) || !strings.HasPrefix(errs[1].Error(), "rollback: ") {
+		t.Errorf("expected the start error and the rollback error of db, got %v", errs)
+	}
+
+	for _, name := range []string{"db", "cache", "repo"} {
+		if o.index("stop "+name) < 0 {
+			t.Errorf("started %s isn't rolled back: %v", name, o.calls)
+		}
+	}
+	for _, call := range []string{"stop queue", "start api", "stop api"} {
+		if o.index(call) >= 0 {
+			t.Errorf("unexpected %q: %v", call, o.calls)
+		}
+	}
+	if o.index("stop repo") > o.index("stop db") {
+		t.Errorf("repo is rolled back after its dependency: %v", o.calls)
+	}
+}
+
+func TestRollbackStopsStarterFinishedAfterTimeout(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		compOpts []ComponentOption
+		startErr error
+		stopped  bool
+	}{
+		{name: "component timeout", compOpts: []ComponentOption{WithStartTimeout(20 * time.Millisecond)}, stopped: true},
+		{name: "total timeout", opts: []Option{WithTotalStartTimeout(20 * time.Millisecond)}, stopped: true},
+		{
+			name:     "failed after timeout",
+			compOpts: []ComponentOption{WithStartTimeout(20 * time.Millisecond)},
+			startErr: errors.New("connection refused"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var o order
+			lc := New(tt.opts...)
+			c := recordingComponent{name: "db", o: &o, startErr: tt.startErr, delay: 100 * time.Millisecond}
+			if err := lc.Add(c, append([]ComponentOption{WithName("db")}, tt.compOpts...)...); err != nil {
+				t.Fatal(err)
+			}
+
+			err := lc.Serve(context.Background())
+			if !errors.Is(err, ErrStartTimeout) {
+				t.Fatalf("expected ErrStartTimeout, got %v", err)
+			}
+			if o.index("start db") < 0 {
+				t.Fatal("Serve hasn't waited for the abandoned starter")
+			}
+			if stopped := o.index("stop db") >= 0; stopped != tt.stopped {
+				t.Errorf("db is stopped: %v, want %v", stopped, tt.stopped)
+			}
+		})
+	}
+}
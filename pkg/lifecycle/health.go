@@ -0,0 +1,120 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/vivid-money/article-golang-di/pkg/internal/recovery"
+)
+
+const (
+	// DefaultCheckInterval is the interval between health checks of a component.
+	DefaultCheckInterval = 10 * time.Second
+	// DefaultCheckTimeout limits a single health check of a component.
+	DefaultCheckTimeout = time.Second
+)
+
+// Health is a result of health checks.
+type Health string
+
+const (
+	HealthUnknown   Health = "unknown"   // the component isn't checked yet or isn't a HealthChecker
+	HealthHealthy   Health = "healthy"   // all checks are passed
+	HealthDegraded  Health = "degraded"  // only non-critical components are unhealthy
+	HealthUnhealthy Health = "unhealthy" // the check is failed; for the application, a critical component is unhealthy
+)
+
+// WithHealthCheck sets the interval between health checks of the component and the timeout of a single check.
+// A non-positive interval or timeout means the default one, DefaultCheckInterval or DefaultCheckTimeout.
+func WithHealthCheck(interval, timeout time.Duration) ComponentOption {
+	if interval <= 0 {
+		interval = DefaultCheckInterval
+	}
+	if timeout <= 0 {
+		timeout = DefaultCheckTimeout
+	}
+	return func(c *component) {
+		c.checkInterval = interval
+		c.checkTimeout = timeout
+	}
+}
+
+// NonCritical marks the component as non-critical: when it's unhealthy, the application is degraded but still ready.
+func NonCritical() ComponentOption {
+	return func(c *component) {
+		c.nonCritical = true
+	}
+}
+
+// Health aggregates the cached results of the health checks of all components.
+func (l *Lifecycle) Health() Health {
+	res := HealthHealthy
+	for _, st := range l.Snapshot() {
+		if st.Health != HealthUnhealthy {
+			continue
+		}
+		if st.Critical {
+			return HealthUnhealthy
+		}
+		res = HealthDegraded
+	}
+	return res
+}
+
+// startHealthChecks periodically checks the health of all started health checkers until the lifecycle is stopped.
+// The returned function waits until all checks are finished.
+func (l *Lifecycle) startHealthChecks(nodes []*node) (wait func()) {
+	var wg sync.WaitGroup
+	for _, n := range nodes {
+		if n.r == nil || n.c.check == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(c *component) {
+			defer wg.Done()
+			ticker := time.NewTicker(c.checkInterval)
+			defer ticker.Stop()
+			for {
+				l.checkHealth(c)
+				select {
+				case <-ticker.C:
+				case <-l.stopCh:
+					return
+				}
+			}
+		}(n.c)
+	}
+	return wg.Wait
+}
+
+func (l *Lifecycle) checkHealth(c *component) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.checkTimeout)
+	defer cancel()
+	checked := make(chan error, 1)
+	go func() {
+		checked <- recovery.Catch(c.check)(ctx) // a panic makes the component unhealthy
+	}()
+	var err error
+	select {
+	case err = <-checked:
+	case <-ctx.Done():
+		err = fmt.Errorf("health check isn't finished after %s: %w", c.checkTimeout, ctx.Err())
+	}
+
+	health := HealthHealthy
+	if err != nil {
+		health = HealthUnhealthy
+	}
+	l.mtx.Lock()
+	st := l.states[c.name]
+	changed := st.Health != health
+	st.Health = health
+	st.HealthErr = err
+	st.CheckedAt = time.Now()
+	l.mtx.Unlock()
+	if changed {
+		l.notify(Event{Type: EventHealthChanged, Component: c.name, Err: err})
+	}
+}
@@ -0,0 +1,155 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type checker struct{}
+
+func (checker) Check(_ context.Context) error { return nil }
+
+func TestZeroHealthCheckOptionsUseDefaults(t *testing.T) {
+	checked := make(chan Event, 1)
+	lc := New(WithObserver(ObserverFunc(func(e Event) {
+		if e.Type == EventHealthChanged {
+			checked <- e
+		}
+	})))
+	if err := lc.Add(checker{}, WithName("db"), WithHealthCheck(0, 0)); err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan error, 1)
+	go func() { served <- lc.Serve(context.Background()) }()
+
+	if e := <-checked; e.Err != nil {
+		t.Errorf("the check has failed: %v", e.Err)
+	}
+	if err := lc.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-served; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// switchable is healthy until it's broken.
+type switchable struct {
+	mtx sync.Mutex
+	err error
+}
+
+func (s *switchable) Check(_ context.Context) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.err
+}
+
+func (s *switchable) set(err error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.err = err
+}
+
+func TestHealthAndReadiness(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []ComponentOption
+		health   Health
+		notReady bool
+	}{
+		{name: "critical", health: HealthUnhealthy, notReady: true},
+		{name: "non-critical", opts: []ComponentOption{NonCritical()}, health: HealthDegraded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed := make(chan Event, 10)
+			lc := New(WithObserver(ObserverFunc(func(e Event) {
+				if e.Type == EventHealthChanged {
+					changed <- e
+				}
+			})))
+			cache := &switchable{}
+			opts := append([]ComponentOption{WithName("cache"), WithHealthCheck(10*time.Millisecond, time.Second)}, tt.opts...)
+			if err := lc.Add(cache, opts...); err != nil {
+				t.Fatal(err)
+			}
+			lc.AddShutdowner(func(context.Context) error { return nil }, WithName("db"))
+			served := make(chan error, 1)
+			go func() { served <- lc.Serve(context.Background()) }()
+
+			<-changed
+			if lc.Health() != HealthHealthy || lc.Readiness() != nil {
+				t.Fatalf("healthy application: %s, %v", lc.Health(), lc.Readiness())
+			}
+			errLost := errors.New("connection lost")
+			cache.set(errLost)
+			if e := <-changed; !errors.Is(e.Err, errLost) {
+				t.Fatalf("unexpected health change: %v", e.Err)
+			}
+			if h := lc.Health(); h != tt.health {
+				t.Errorf("Health() = %s, want %s", h, tt.health)
+			}
+			if err := lc.Readiness(); errors.Is(err, ErrNotReady) != tt.notReady {
+				t.Errorf("Readiness() = %v, want not ready: %v", err, tt.notReady)
+			}
+
+			cache.set(nil)
+			if e := <-changed; e.Err != nil {
+				t.Fatalf("unexpected health change: %v", e.Err)
+			}
+			if lc.Health() != HealthHealthy || lc.Readiness() != nil {
+				t.Errorf("recovered application: %s, %v", lc.Health(), lc.Readiness())
+			}
+			if err := lc.Stop(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			<-served
+		})
+	}
+}
+
+// hangingChecker never answers until the test is finished.
+type hangingChecker struct {
+	release chan struct{}
+}
+
+func (c hangingChecker) Check(_ context.Context) error {
+	<-c.release
+	return nil
+}
+
+func TestHangingHealthCheckTimesOut(t *testing.T) {
+	changed := make(chan Event, 1)
+	lc := New(WithObserver(ObserverFunc(func(e Event) {
+		if e.Type == EventHealthChanged {
+			changed <- e
+		}
+	})))
+	c := hangingChecker{release: make(chan struct{})}
+	defer close(c.release)
+	if err := lc.Add(c, WithName("db"), WithHealthCheck(time.Minute, 20*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan error, 1)
+	go func() { served <- lc.Serve(context.Background()) }()
+
+	select {
+	case e := <-changed:
+		if !errors.Is(e.Err, context.DeadlineExceeded) {
+			t.Errorf("unexpected health error: %v", e.Err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("hanging check isn't timed out")
+	}
+	if lc.Health() != HealthUnhealthy {
+		t.Errorf("Health() = %s, want unhealthy", lc.Health())
+	}
+	if err := lc.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	<-served
+}
@@ -0,0 +1,287 @@
+// Package lifecycle starts application components in dependency (by default, registration) order and stops them
+// in reverse order.
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"runtime/pprof"
+	"sync"
+	"time"
+
+	"go.uber.org/multierr"
+)
+
+// Lifecycle keeps registered components and controls their start and stop.
+type Lifecycle struct {
+	startTimeout    time.Duration
+	stopTimeout     time.Duration
+	escalationGrace time.Duration
+	drainPeriod     time.Duration
+	escalate        func(err error)
+	observers       []Observer
+
+	mtx        sync.Mutex
+	components []*component
+	addErr     error
+	serving    bool
+	states     map[string]*ComponentState
+	running    []*node // the graph of the started application, nil while starting or stopping
+	draining   bool    // readiness is turned off before the stop
+
+	reloadMtx sync.Mutex
+
+	stopOnce  sync.Once
+	stopCh    chan struct{}
+	stopCtx   context.Context
+	startedCh chan struct{} // closed when all components are started
+	doneCh    chan struct{}
+	err       error
+}
+
+func New(opts ...Option) *Lifecycle {
+	l := &Lifecycle{
+		escalationGrace: DefaultEscalationGrace,
+		escalate:        dumpAndExit,
+		states:          make(map[string]*ComponentState),
+		stopCh:          make(chan struct{}),
+		startedCh:       make(chan struct{}),
+		doneCh:          make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// Add registers a component by the interfaces it implements: Server (optionally Readier), Starter (or Connector),
+// Shutdowner, Reloader, HealthChecker and Drainer. It returns ErrNoRoles if the component implements none of them.
+func (l *Lifecycle) Add(v interface{}, opts ...ComponentOption) error {
+	c := &component{}
+	if s, ok := v.(Starter); ok {
+		c.start = s.Start
+	} else if s, ok := v.(Connector); ok {
+		c.start = s.Connect
+	}
+	if s, ok := v.(Server); ok {
+		c.serve = s.Serve
+		if r, ok := v.(Readier); ok {
+			c.ready = r.Ready
+		}
+	}
+	if s, ok := v.(Shutdowner); ok {
+		c.stop = s.Stop
+	}
+	if r, ok := v.(Reloader); ok {
+		c.reload = r.Reload
+	}
+	if h, ok := v.(HealthChecker); ok {
+		c.check = h.Check
+	}
+	if d, ok := v.(Drainer); ok {
+		c.drain = d.Drain
+	}
+	if c.start == nil && c.serve == nil && c.stop == nil && c.reload == nil && c.check == nil && c.drain == nil {
+		return fmt.Errorf("can't add %T: %w", v, ErrNoRoles)
+	}
+
+	return l.add(c, typeName(v), opts)
+}
+
+// typeName returns the name of the type of v without the package and the pointer, like "DBConn".
+func typeName(v interface{}) string {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// AddServer registers a function that will be run in a separate goroutine by Serve.
+// The context passed to the server is cancelled when the server is stopped.
+func (l *Lifecycle) AddServer(server func(ctx context.Context) error, opts ...ComponentOption) *Lifecycle {
+	l.addOrRemember(&component{serve: server}, opts)
+	return l
+}
+
+// AddStarter registers a non-blocking function that will be called by Serve before the next component is started.
+func (l *Lifecycle) AddStarter(starter func(ctx context.Context) error, opts ...ComponentOption) *Lifecycle {
+	l.addOrRemember(&component{start: starter}, opts)
+	return l
+}
+
+// AddShutdowner registers a function that will be called by Stop in reverse registration order.
+func (l *Lifecycle) AddShutdowner(shutdown func(ctx context.Context) error, opts ...ComponentOption) *Lifecycle {
+	l.addOrRemember(&component{stop: shutdown}, opts)
+	return l
+}
+
+// addOrRemember is used by the chained registration methods: the error is returned later by Serve.
+func (l *Lifecycle) addOrRemember(c *component, opts []ComponentOption) {
+	if err := l.add(c, "", opts); err != nil {
+		l.mtx.Lock()
+		l.addErr = multierr.Append(l.addErr, err)
+		l.mtx.Unlock()
+	}
+}
+
+func (l *Lifecycle) add(c *component, name string, opts []ComponentOption) error {
+	c.startTimeout = DefaultStartTimeout
+	c.stopTimeout = DefaultStopTimeout
+	c.restartBackoff = DefaultRestartBackoff
+	c.maxRestartBackoff = DefaultMaxRestartBackoff
+	c.checkInterval = DefaultCheckInterval
+	c.checkTimeout = DefaultCheckTimeout
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if c.name == "" {
+		// The registration number is added only when it's needed: to functions and to repeated types.
+		if name == "" || l.states[name] != nil {
+			if name == "" {
+				name = "component"
+			}
+			name = fmt.Sprintf("%s #%d", name, len(l.components)+1)
+		}
+		c.name = name
+	}
+	for _, other := range l.components {
+		if other.name == c.name {
+			return fmt.Errorf("component %q is already registered", c.name)
+		}
+	}
+	if !c.explicitDeps && len(l.components) > 0 {
+		// Without explicit dependencies components are started one by one in registration order.
+		c.deps = []string{l.components[len(l.components)-1].name}
+	}
+	if cycle := findCycle(l.components, c); cycle != "" {
+		return fmt.Errorf("can't add %s: dependency cycle %s", c.name, cycle)
+	}
+	l.components = append(l.components, c)
+	l.states[c.name] = &ComponentState{
+		Name:     c.name,
+		Roles:    c.roles(),
+		State:    StateRegistered,
+		Since:    time.Now(),
+		Critical: !c.nonCritical,
+		Health:   HealthUnknown,
+	}
+	return nil
+}
+
+// Serve starts all registered components and blocks until ctx is done, Stop is called or one of the servers fails.
+// A component is started after all of its dependencies (by default, the previously registered component) are started:
+// starters must return and servers, launched in separate goroutines, must become ready. After that all started
+// components are stopped, each one only after all components depending on it are stopped. Before the stop of
+// the started application readiness is turned off and the drainers are notified, see WithDrainPeriod.
+// If the start fails, exactly the components which had been started are stopped and the returned error contains
+// both the start error and the errors of this rollback.
+func (l *Lifecycle) Serve(ctx context.Context) error {
+	l.mtx.Lock()
+	if l.serving {
+		l.mtx.Unlock()
+		return ErrAlreadyServing
+	}
+	if l.addErr != nil {
+		l.mtx.Unlock()
+		return &StartError{Err: l.addErr}
+	}
+	l.serving = true
+	components := make([]*component, len(l.components))
+	copy(components, l.components)
+	l.mtx.Unlock()
+	defer close(l.doneCh)
+
+	err := l.serve(ctx, components)
+
+	l.mtx.Lock()
+	l.err = err
+	l.mtx.Unlock()
+	return err
+}
+
+func (l *Lifecycle) serve(ctx context.Context, components []*component) error {
+	nodes, err := buildGraph(components)
+	if err != nil {
+		return &StartError{Err: err}
+	}
+	failed := make(chan error, len(nodes))
+	var startErr error
+	if err = l.startAll(ctx, nodes, failed); err != nil {
+		startErr = &StartError{Err: err}
+		err = startErr
+	} else {
+		l.mtx.Lock()
+		l.running = nodes
+		l.mtx.Unlock()
+		close(l.startedCh)
+		waitChecks := l.startHealthChecks(nodes)
+		select {
+		case <-ctx.Done():
+		case <-l.stopCh:
+		case err = <-failed:
+		}
+		l.stopOnce.Do(func() { close(l.stopCh) })
+		waitChecks()
+		err = multierr.Append(err, l.drain(nodes))
+	}
+
+	l.stopOnce.Do(func() { close(l.stopCh) })
+	l.mtx.Lock()
+	l.running = nil
+	l.mtx.Unlock()
+	l.reloadMtx.Lock() // waits for the reload in progress, if any: it's already given up because stopCh is closed
+	l.reloadMtx.Unlock()
+	stopErr := l.stopAll(nodes)
+	if startErr != nil {
+		// Only the components which had been started are stopped, so these are errors of the rollback.
+		for _, e := range multierr.Errors(stopErr) {
+			err = multierr.Append(err, fmt.Errorf("rollback: %w", e))
+		}
+	} else {
+		err = multierr.Append(err, stopErr)
+	}
+	for len(failed) > 0 {
+		err = multierr.Append(err, <-failed)
+	}
+	return err
+}
+
+// Stop asks Serve to stop all components and waits until they are stopped or ctx is done.
+// The ctx is also passed to the shutdowners of the components.
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	l.stopOnce.Do(func() {
+		l.mtx.Lock()
+		l.stopCtx = ctx
+		l.mtx.Unlock()
+		close(l.stopCh)
+	})
+
+	l.mtx.Lock()
+	serving := l.serving
+	l.mtx.Unlock()
+	if !serving {
+		return nil
+	}
+
+	select {
+	case <-l.doneCh:
+		l.mtx.Lock()
+		defer l.mtx.Unlock()
+		return l.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// dumpAndExit is the default stop escalation: there is nothing left to do gracefully, so it shows what's stuck.
+func dumpAndExit(err error) {
+	_, _ = fmt.Fprintf(os.Stderr, "%v, dumping goroutines:\n", err)
+	_ = pprof.Lookup("goroutine").WriteTo(os.Stderr, 2)
+	os.Exit(ExitStopTimeout)
+}
@@ -0,0 +1,94 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type (
+	starterOnly   struct{}
+	connectorOnly struct{}
+	serverOnly    struct{}
+	stopperOnly   struct{}
+	readyServer   struct{ serverOnly }
+	readyOnly     struct{}
+	// dbConn is both a Starter and a Connector, like a connection with both methods.
+	dbConn struct{ connectorOnly }
+	// everything implements all of the roles.
+	everything struct {
+		starterOnly
+		readyServer
+		stopperOnly
+		checker
+		noopReloader
+		noopDrainer
+	}
+	noopReloader struct{}
+	noopDrainer  struct{}
+)
+
+func (starterOnly) Start(_ context.Context) error { return nil }
+
+func (connectorOnly) Connect(_ context.Context) error { return nil }
+
+func (serverOnly) Serve(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+func (stopperOnly) Stop(_ context.Context) error { return nil }
+
+func (readyServer) Ready() <-chan struct{} { return nil }
+
+func (readyOnly) Ready() <-chan struct{} { return nil }
+
+func (dbConn) Start(_ context.Context) error { return nil }
+
+func (noopReloader) Reload(_ context.Context) error { return nil }
+
+func (noopDrainer) Drain(_ context.Context) error { return nil }
+
+func TestAddDetectsRoles(t *testing.T) {
+	tests := []struct {
+		name  string
+		v     interface{}
+		roles []string
+	}{
+		{name: "starter", v: starterOnly{}, roles: []string{"starter"}},
+		{name: "connector", v: connectorOnly{}, roles: []string{"starter"}},
+		{name: "starter and connector", v: dbConn{}, roles: []string{"starter"}},
+		{name: "server", v: serverOnly{}, roles: []string{"server"}},
+		{name: "readier", v: readyServer{}, roles: []string{"server", "readier"}},
+		{name: "shutdowner", v: &stopperOnly{}, roles: []string{"shutdowner"}},
+		{
+			name:  "all roles",
+			v:     everything{},
+			roles: []string{"starter", "server", "readier", "shutdowner", "reloader", "health_checker", "drainer"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lc := New()
+			if err := lc.Add(tt.v); err != nil {
+				t.Fatal(err)
+			}
+			if roles := lc.Snapshot()[0].Roles; !reflect.DeepEqual(roles, tt.roles) {
+				t.Errorf("roles %v, want %v", roles, tt.roles)
+			}
+		})
+	}
+}
+
+func TestAddWithoutRoles(t *testing.T) {
+	for _, v := range []interface{}{struct{}{}, 42, readyOnly{}} {
+		lc := New()
+		if err := lc.Add(v); !errors.Is(err, ErrNoRoles) {
+			t.Errorf("Add(%T) = %v, want ErrNoRoles", v, err)
+		}
+		if n := len(lc.Snapshot()); n != 0 {
+			t.Errorf("%T is registered", v)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package lifecycle
+
+import (
+	"time"
+
+	"github.com/vivid-money/article-golang-di/pkg/components"
+)
+
+// EventType is a kind of a transition of a component.
+type EventType int
+
+const (
+	EventStarting EventType = iota
+	EventStarted
+	EventStartFailed
+	EventStopping
+	EventStopped
+	EventServerExited
+	EventReloading
+	EventReloaded
+	EventRestarting
+	EventRestarted
+	EventHealthChanged
+	EventDraining
+	EventDrained
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventStarting:
+		return "Starting"
+	case EventStarted:
+		return "Started"
+	case EventStartFailed:
+		return "StartFailed"
+	case EventStopping:
+		return "Stopping"
+	case EventStopped:
+		return "Stopped"
+	case EventServerExited:
+		return "ServerExited"
+	case EventReloading:
+		return "Reloading"
+	case EventReloaded:
+		return "Reloaded"
+	case EventRestarting:
+		return "Restarting"
+	case EventRestarted:
+		return "Restarted"
+	case EventHealthChanged:
+		return "HealthChanged"
+	case EventDraining:
+		return "Draining"
+	case EventDrained:
+		return "Drained"
+	default:
+		return "Unknown"
+	}
+}
+
+// Event describes a transition of a component.
+type Event struct {
+	Type      EventType
+	Component string
+	// Duration is the time spent since the start of the transition: since Starting for Started and StartFailed,
+	// since Stopping for Stopped, since Reloading for Reloaded, since Draining for Drained, since the launch of
+	// the server for ServerExited and since Restarting for Restarted. For Restarting, it's the delay before the restart.
+	Duration time.Duration
+	Err      error
+	// Restarts is the number of restarts of the server: done before ServerExited, being done by Restarting or just
+	// done by Restarted.
+	Restarts int
+	// Roles are the roles of the component, like in ComponentState. They mustn't be modified.
+	Roles []string
+}
+
+// Observer receives the events of all components. It's called synchronously from different goroutines,
+// so it must be safe for concurrent use and shouldn't block.
+type Observer interface {
+	OnEvent(e Event)
+}
+
+// ObserverFunc is an adapter to use an ordinary function as an Observer.
+type ObserverFunc func(e Event)
+
+func (f ObserverFunc) OnEvent(e Event) {
+	f(e)
+}
+
+// WithObserver adds an observer of the events of all components.
+func WithObserver(o Observer) Option {
+	return func(l *Lifecycle) {
+		l.observers = append(l.observers, o)
+	}
+}
+
+// LoggerObserver writes events to the logger in the same format the components do: "Connecting DBConn",
+// "Connected DBConn", "Stop DBConn", "Stopped DBConn". The start of a server is logged as "Serving" and "Ready".
+type LoggerObserver struct {
+	logger components.Logger
+}
+
+func NewLoggerObserver(logger components.Logger) *LoggerObserver {
+	return &LoggerObserver{logger: logger}
+}
+
+func (o *LoggerObserver) OnEvent(e Event) {
+	switch e.Type {
+	case EventStarting:
+		if hasRole(e.Roles, "starter") {
+			o.logger.Printf("Connecting %s", e.Component)
+			return
+		}
+		o.logger.Printf("Serving %s", e.Component)
+	case EventStarted:
+		if hasRole(e.Roles, "server") {
+			o.logger.Printf("Ready %s in %s", e.Component, e.Duration)
+			return
+		}
+		o.logger.Printf("Connected %s in %s", e.Component, e.Duration)
+	case EventStartFailed:
+		o.logger.Printf("Failed to start %s in %s: %v", e.Component, e.Duration, e.Err)
+	case EventStopping:
+		o.logger.Printf("Stop %s", e.Component)
+	case EventStopped:
+		if e.Err != nil {
+			o.logger.Printf("Stopped %s in %s with error: %v", e.Component, e.Duration, e.Err)
+			return
+		}
+		o.logger.Printf("Stopped %s in %s", e.Component, e.Duration)
+	case EventServerExited:
+		if e.Err != nil {
+			o.logger.Printf("Finished serving %s with error: %v", e.Component, e.Err)
+			return
+		}
+		o.logger.Printf("Finished serving %s", e.Component)
+	case EventRestarting:
+		o.logger.Printf("Restart %s in %s (restart #%d) after: %v", e.Component, e.Duration, e.Restarts, e.Err)
+	case EventRestarted:
+		o.logger.Printf("Serving %s again in %s (restart #%d)", e.Component, e.Duration, e.Restarts)
+	case EventHealthChanged:
+		if e.Err != nil {
+			o.logger.Printf("Unhealthy %s: %v", e.Component, e.Err)
+			return
+		}
+		o.logger.Printf("Healthy %s", e.Component)
+	case EventDraining:
+		o.logger.Printf("Drain %s", e.Component)
+	case EventDrained:
+		if e.Err != nil {
+			o.logger.Printf("Drained %s in %s with error: %v", e.Component, e.Duration, e.Err)
+			return
+		}
+		o.logger.Printf("Drained %s in %s", e.Component, e.Duration)
+	case EventReloading:
+		o.logger.Printf("Reload %s", e.Component)
+	case EventReloaded:
+		if e.Err != nil {
+			o.logger.Printf("Reloaded %s in %s with error: %v", e.Component, e.Duration, e.Err)
+			return
+		}
+		o.logger.Printf("Reloaded %s in %s", e.Component, e.Duration)
+	}
+}
+
+func hasRole(roles []string, role string) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *Lifecycle) notify(e Event) {
+	l.mtx.Lock()
+	if st, ok := l.states[e.Component]; ok {
+		e.Roles = st.Roles
+	}
+	l.track(e)
+	l.mtx.Unlock()
+	for _, o := range l.observers {
+		o.OnEvent(e)
+	}
+}
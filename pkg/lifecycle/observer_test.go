@@ -0,0 +1,81 @@
+package lifecycle
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"regexp"
+	"testing"
+)
+
+// Conn is a starter named like the components of the examples.
+type Conn struct{}
+
+func (*Conn) Connect(_ context.Context) error { return nil }
+
+func (*Conn) Stop(_ context.Context) error { return nil }
+
+// API is a server named like the components of the examples.
+type API struct{}
+
+func (API) Serve(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+func TestDefaultNames(t *testing.T) {
+	lc := New()
+	for _, v := range []interface{}{&Conn{}, API{}, &Conn{}} {
+		if err := lc.Add(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lc.AddStarter(func(context.Context) error { return nil })
+
+	var names []string
+	for _, st := range lc.Snapshot() {
+		names = append(names, st.Name)
+	}
+	want := []string{"Conn", "API", "Conn #3", "component #4"}
+	if len(names) != len(want) {
+		t.Fatalf("names %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("names %v, want %v", names, want)
+			break
+		}
+	}
+}
+
+func TestLoggerObserver(t *testing.T) {
+	var buf bytes.Buffer
+	lc := New(WithObserver(NewLoggerObserver(log.New(&buf, "", 0))))
+	if err := lc.Add(&Conn{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := lc.Add(API{}); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		<-lc.startedCh
+		_ = lc.Stop(context.Background())
+	}()
+	if err := lc.Serve(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := regexp.MustCompile(`^Connecting Conn
+Connected Conn in \S+
+Serving API
+Ready API in \S+
+Stop API
+Finished serving API
+Stopped API in \S+
+Stop Conn
+Stopped Conn in \S+
+$`)
+	if !want.MatchString(buf.String()) {
+		t.Errorf("unexpected log:\n%s", buf.String())
+	}
+}
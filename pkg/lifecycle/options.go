@@ -0,0 +1,76 @@
+package lifecycle
+
+import "time"
+
+const (
+	// DefaultStartTimeout is used for components registered without WithStartTimeout.
+	DefaultStartTimeout = 15 * time.Second
+	// DefaultStopTimeout is used for components registered without WithStopTimeout.
+	DefaultStopTimeout = 15 * time.Second
+	// DefaultEscalationGrace is the time the stop may take after the total stop timeout before it escalates.
+	DefaultEscalationGrace = 2 * time.Second
+)
+
+// Option configures a Lifecycle.
+type Option func(l *Lifecycle)
+
+// WithTotalStartTimeout limits the time Serve may spend starting all components. Zero means no limit.
+func WithTotalStartTimeout(d time.Duration) Option {
+	return func(l *Lifecycle) {
+		l.startTimeout = d
+	}
+}
+
+// WithTotalStopTimeout limits the time all components may spend stopping. Zero means no limit.
+// The components which aren't stopped in time are given up with ErrStopTimeout. If even that doesn't finish
+// the stop in DefaultEscalationGrace, the lifecycle escalates, see WithStopEscalation.
+func WithTotalStopTimeout(d time.Duration) Option {
+	return func(l *Lifecycle) {
+		l.stopTimeout = d
+	}
+}
+
+// WithStopEscalation replaces the default reaction on the stop which hasn't finished in the total stop timeout
+// and DefaultEscalationGrace after it, which dumps all goroutines to stderr and exits the process.
+func WithStopEscalation(escalate func(err error)) Option {
+	return func(l *Lifecycle) {
+		l.escalate = escalate
+	}
+}
+
+// ComponentOption configures a single registered component.
+type ComponentOption func(c *component)
+
+// WithName sets the name of the component which is used in errors and by DependsOn.
+// By default it's the name of the type of the component, like "DBConn", with its registration number added
+// if the type is already registered, like "DBConn #2". Functions are named like "component #3".
+func WithName(name string) ComponentOption {
+	return func(c *component) {
+		c.name = name
+	}
+}
+
+// DependsOn sets the names of the components which must be started before the component and stopped after it.
+// Components without DependsOn depend on the previously registered component; DependsOn without names makes
+// the component independent, so it's started and stopped concurrently with others.
+func DependsOn(names ...string) ComponentOption {
+	return func(c *component) {
+		c.deps = append(c.deps, names...)
+		c.explicitDeps = true
+	}
+}
+
+// WithStartTimeout sets how long Serve waits for the component to start and become ready before giving up.
+// Zero means no limit.
+func WithStartTimeout(d time.Duration) ComponentOption {
+	return func(c *component) {
+		c.startTimeout = d
+	}
+}
+
+// WithStopTimeout sets how long the component may spend stopping. Zero means no limit.
+func WithStopTimeout(d time.Duration) ComponentOption {
+	return func(c *component) {
+		c.stopTimeout = d
+	}
+}
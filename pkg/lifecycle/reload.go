@@ -0,0 +1,90 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/multierr"
+
+	"github.com/vivid-money/article-golang-di/pkg/internal/recovery"
+)
+
+// Reload calls the reloaders of all running components, dependencies before dependents. Errors of the components
+// are returned together and don't stop the application. It returns ErrNotRunning if the application isn't started
+// yet or is already stopping. A reloader which isn't finished when ctx is done or the application starts stopping
+// is given up, so the stop isn't blocked by it.
+func (l *Lifecycle) Reload(ctx context.Context) error {
+	l.reloadMtx.Lock()
+	defer l.reloadMtx.Unlock()
+
+	l.mtx.Lock()
+	nodes := l.running
+	l.mtx.Unlock()
+	if nodes == nil {
+		return ErrNotRunning
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-l.stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	var err error
+	for _, n := range topoOrder(nodes) {
+		if n.r == nil || n.c.reload == nil {
+			continue
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return multierr.Append(err, ctxErr)
+		}
+		err = multierr.Append(err, l.reload(ctx, n.c))
+	}
+	return err
+}
+
+func (l *Lifecycle) reload(ctx context.Context, c *component) error {
+	begin := time.Now()
+	l.notify(Event{Type: EventReloading, Component: c.name})
+	reloaded := make(chan error, 1)
+	go func() {
+		reloaded <- recovery.Catch(c.reload)(ctx)
+	}()
+	var err error
+	select {
+	case err = <-reloaded:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		err = fmt.Errorf("can't reload %s: %w", c.name, err)
+	}
+	l.notify(Event{Type: EventReloaded, Component: c.name, Duration: time.Since(begin), Err: err})
+	return err
+}
+
+// topoOrder returns the nodes so that every node goes after all of its dependencies.
+func topoOrder(nodes []*node) []*node {
+	res := make([]*node, 0, len(nodes))
+	visited := make(map[*node]bool, len(nodes))
+	var visit func(n *node)
+	visit = func(n *node) {
+		if visited[n] {
+			return
+		}
+		visited[n] = true
+		for _, dep := range n.deps {
+			visit(dep)
+		}
+		res = append(res, n)
+	}
+	for _, n := range nodes {
+		visit(n)
+	}
+	return res
+}
@@ -0,0 +1,120 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/vivid-money/article-golang-di/pkg/components"
+)
+
+// hungReloader serves until it's stopped, but its reload never returns until release is closed.
+type hungReloader struct {
+	reloading chan struct{}
+	release   chan struct{}
+}
+
+func (s hungReloader) Serve(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+func (s hungReloader) Reload(_ context.Context) error {
+	close(s.reloading)
+	<-s.release
+	return nil
+}
+
+func newHungReloader() hungReloader {
+	return hungReloader{reloading: make(chan struct{}), release: make(chan struct{})}
+}
+
+func TestStopIsNotBlockedByHungReload(t *testing.T) {
+	s := newHungReloader()
+	defer close(s.release)
+	lc := New()
+	if err := lc.Add(s, WithName("config")); err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan error, 1)
+	go func() { served <- lc.Serve(context.Background()) }()
+	<-lc.startedCh
+
+	reloaded := make(chan error, 1)
+	go func() { reloaded <- lc.Reload(context.Background()) }()
+	<-s.reloading
+	if err := lc.Stop(context.Background()); err != nil {
+		t.Fatalf("can't stop: %v", err)
+	}
+
+	select {
+	case err := <-reloaded:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected the reload to be given up, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("reload isn't given up on stop")
+	}
+	select {
+	case <-served:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve is blocked by the reload")
+	}
+}
+
+func TestReloadTimeout(t *testing.T) {
+	s := newHungReloader()
+	defer close(s.release)
+	lc := New()
+	if err := lc.Add(s, WithName("config")); err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = lc.Serve(context.Background()) }()
+	defer func() { _ = lc.Stop(context.Background()) }()
+	<-lc.startedCh
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := lc.Reload(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestRunHandlesStopSignalDuringReload(t *testing.T) {
+	s := newHungReloader()
+	defer close(s.release)
+	lc := New()
+	if err := lc.Add(s, WithName("config")); err != nil {
+		t.Fatal(err)
+	}
+	exited := make(chan int, 1)
+	go func() {
+		exited <- Run(context.Background(), lc,
+			WithRunLogger(log.New(ioutil.Discard, "", 0)),
+			WithSignals(components.NewSignals(syscall.SIGUSR2)),
+			WithReloadSignals(syscall.SIGUSR1),
+		)
+	}()
+	<-lc.startedCh
+
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatal(err)
+	}
+	<-s.reloading
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR2); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case code := <-exited:
+		if code != ExitOK {
+			t.Errorf("exit code %d, want %d", code, ExitOK)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stop signal isn't handled during the reload")
+	}
+}
@@ -0,0 +1,144 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vivid-money/article-golang-di/pkg/internal/recovery"
+)
+
+// RestartPolicy defines whether a server is restarted after it exits by itself.
+type RestartPolicy int
+
+const (
+	// RestartNever is the default policy: a failure of the server stops the application.
+	RestartNever RestartPolicy = iota
+	// RestartOnFailure restarts the server if it returns an error.
+	RestartOnFailure
+	// RestartAlways restarts the server whenever it returns, even without an error.
+	RestartAlways
+)
+
+const (
+	// DefaultRestartBackoff is the delay before the first restart of a server.
+	DefaultRestartBackoff = 100 * time.Millisecond
+	// DefaultMaxRestartBackoff is the limit the restart delay grows up to.
+	DefaultMaxRestartBackoff = 10 * time.Second
+)
+
+func (p RestartPolicy) String() string {
+	switch p {
+	case RestartNever:
+		return "never"
+	case RestartOnFailure:
+		return "on-failure"
+	case RestartAlways:
+		return "always"
+	default:
+		return "unknown"
+	}
+}
+
+func (p RestartPolicy) shouldRestart(err error) bool {
+	switch p {
+	case RestartOnFailure:
+		return err != nil
+	case RestartAlways:
+		return true
+	default:
+		return false
+	}
+}
+
+// WithRestart sets the restart policy of the server. After maxRestarts restarts the next exit of the server is
+// handled as if the policy was RestartNever; a negative maxRestarts means no limit.
+func WithRestart(policy RestartPolicy, maxRestarts int) ComponentOption {
+	return func(c *component) {
+		c.restartPolicy = policy
+		c.maxRestarts = maxRestarts
+	}
+}
+
+// WithRestartBackoff sets the delay before the first restart of the server. The delay is doubled after every restart
+// up to max.
+func WithRestartBackoff(initial, max time.Duration) ComponentOption {
+	return func(c *component) {
+		c.restartBackoff = initial
+		c.maxRestartBackoff = max
+	}
+}
+
+var errExitedWithoutError = errors.New("exited without an error")
+
+// serveWithRestarts runs the server and restarts it according to its policy until it's stopped by the lifecycle
+// or the restart budget is exhausted. It returns the failure of the server, if any.
+func (l *Lifecycle) serveWithRestarts(ctx context.Context, r *running) error {
+	c := r.c
+	delay := c.restartBackoff
+	for restarts := 0; ; restarts++ {
+		begin := time.Now()
+		err := recovery.Catch(c.serve)(ctx) // a panic is a failure like any other
+		stopRequested := false
+		select {
+		case <-r.stopRequested:
+			stopRequested = true
+		default:
+		}
+		if stopRequested && err != nil && isExpectedStopErr(err) {
+			err = nil
+		}
+		select {
+		case <-r.ready:
+			<-r.reported // the exit of the started server is reported after its Started, never before
+		default:
+		}
+		l.notify(Event{
+			Type:      EventServerExited,
+			Component: c.name,
+			Duration:  time.Since(begin),
+			Err:       err,
+			Restarts:  restarts,
+		})
+
+		if stopRequested {
+			if err != nil {
+				return &ServerError{Component: c.name, Err: err}
+			}
+			return nil
+		}
+		// The server has exited by itself, so even without an error it's a reason to stop the application.
+		if !c.restartPolicy.shouldRestart(err) {
+			if err == nil {
+				err = errExitedWithoutError
+			}
+			return &ServerError{Component: c.name, Err: err}
+		}
+		if c.maxRestarts >= 0 && restarts >= c.maxRestarts {
+			if err == nil {
+				err = errExitedWithoutError
+			}
+			return &ServerError{Component: c.name, Err: fmt.Errorf("restarted %d times: %w", restarts, err)}
+		}
+
+		restartBegin := time.Now()
+		l.notify(Event{Type: EventRestarting, Component: c.name, Duration: delay, Err: err, Restarts: restarts + 1})
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-r.stopRequested:
+			timer.Stop()
+			return nil
+		}
+		l.notify(Event{
+			Type:      EventRestarted,
+			Component: c.name,
+			Duration:  time.Since(restartBegin),
+			Restarts:  restarts + 1,
+		})
+		if delay *= 2; delay > c.maxRestartBackoff {
+			delay = c.maxRestartBackoff
+		}
+	}
+}
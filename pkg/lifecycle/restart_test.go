@@ -0,0 +1,82 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyServer is ready at once and crashes on its first run.
+type flakyServer struct {
+	mtx     sync.Mutex
+	runs    int
+	serving chan struct{}
+}
+
+func (s *flakyServer) Serve(ctx context.Context) error {
+	s.mtx.Lock()
+	s.runs++
+	first := s.runs == 1
+	s.mtx.Unlock()
+	if first {
+		return errors.New("crash")
+	}
+	close(s.serving)
+	<-ctx.Done()
+	return nil
+}
+
+func (s *flakyServer) Ready() <-chan struct{} {
+	ready := make(chan struct{})
+	close(ready)
+	return ready
+}
+
+func TestRestartEvents(t *testing.T) {
+	rec := &recorder{}
+	var restarted Event
+	lc := New(WithObserver(rec), WithObserver(ObserverFunc(func(e Event) {
+		if e.Type == EventRestarted {
+			restarted = e
+		}
+	})))
+	s := &flakyServer{serving: make(chan struct{})}
+	err := lc.Add(s, WithName("server"), WithRestart(RestartOnFailure, 1), WithRestartBackoff(10*time.Millisecond, time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan error, 1)
+	go func() { served <- lc.Serve(context.Background()) }()
+	select {
+	case <-s.serving:
+	case <-time.After(5 * time.Second):
+		t.Fatal("server isn't restarted")
+	}
+	if err := lc.Stop(context.Background()); err != nil {
+		t.Fatalf("can't stop: %v", err)
+	}
+	<-served
+
+	want := []string{
+		"Starting server",
+		"Started server",
+		"ServerExited server",
+		"Restarting server",
+		"Restarted server",
+		"Stopping server",
+		"ServerExited server",
+		"Stopped server",
+	}
+	if !reflect.DeepEqual(rec.events, want) {
+		t.Errorf("events %v, want %v", rec.events, want)
+	}
+	if restarted.Restarts != 1 || restarted.Duration < 10*time.Millisecond {
+		t.Errorf("unexpected Restarted event: %+v", restarted)
+	}
+	if st := lc.Snapshot()[0]; st.Restarts != 1 {
+		t.Errorf("restarts %d, want 1", st.Restarts)
+	}
+}
@@ -0,0 +1,45 @@
+package lifecycle
+
+import "context"
+
+// Server is a component which blocks in Serve until it is stopped or fails.
+type Server interface {
+	Serve(ctx context.Context) error
+}
+
+// Starter is a component which starts its work in Start and returns immediately.
+type Starter interface {
+	Start(ctx context.Context) error
+}
+
+// Connector is the same as Starter, but named the way connections usually are.
+type Connector interface {
+	Connect(ctx context.Context) error
+}
+
+// Shutdowner is a component which can be stopped.
+type Shutdowner interface {
+	Stop(ctx context.Context) error
+}
+
+// Readier is an optional interface of a Server. The returned channel is closed once the server is ready to work,
+// e.g. when its listener is bound. Without it a server is considered ready as soon as it is launched.
+type Readier interface {
+	Ready() <-chan struct{}
+}
+
+// Reloader is a component which can reload its configuration or credentials without a restart.
+type Reloader interface {
+	Reload(ctx context.Context) error
+}
+
+// HealthChecker is a component which can check its health, e.g. by a ping query.
+type HealthChecker interface {
+	Check(ctx context.Context) error
+}
+
+// Drainer is a component which is notified before the application is stopped, while it's still running but isn't
+// ready anymore, e.g. to stop accepting new keep-alive connections.
+type Drainer interface {
+	Drain(ctx context.Context) error
+}
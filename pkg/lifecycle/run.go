@@ -0,0 +1,156 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/vivid-money/article-golang-di/pkg/components"
+)
+
+// Exit codes returned by Run.
+const (
+	ExitOK           = 0
+	ExitFailure      = 1 // any error which isn't classified below, e.g. a failed shutdowner
+	ExitStartFailed  = 2
+	ExitServerFailed = 3
+	ExitStopTimeout  = 4
+	ExitAborted      = 5 // a second signal was received while stopping
+)
+
+// DefaultReloadTimeout limits a reload started by a signal, see WithReloadSignals.
+const DefaultReloadTimeout = 30 * time.Second
+
+type runConfig struct {
+	logger        components.Logger
+	signals       *components.Signals
+	reloadSignals []os.Signal
+	reloadTimeout time.Duration
+	onSecond      func(stopping []string)
+}
+
+// RunOption configures Run.
+type RunOption func(cfg *runConfig)
+
+// WithRunLogger sets the logger Run writes the resulting error to. By default it's stderr.
+func WithRunLogger(logger components.Logger) RunOption {
+	return func(cfg *runConfig) {
+		cfg.logger = logger
+	}
+}
+
+// WithSignals sets the signals which stop the lifecycle and the handlers of other signals.
+// By default the lifecycle is stopped by SIGINT and SIGTERM.
+func WithSignals(signals *components.Signals) RunOption {
+	return func(cfg *runConfig) {
+		cfg.signals = signals
+	}
+}
+
+// WithReloadSignals makes Run reload the lifecycle instead of stopping it when one of the signals is received,
+// usually SIGHUP. The reload runs in the background, so the stop signals are still handled; a signal received
+// while the previous reload is in progress is ignored. Reload errors are written to the logger.
+func WithReloadSignals(signals ...os.Signal) RunOption {
+	return func(cfg *runConfig) {
+		cfg.reloadSignals = append(cfg.reloadSignals, signals...)
+	}
+}
+
+// WithReloadTimeout limits a reload started by a signal, DefaultReloadTimeout by default.
+func WithReloadTimeout(d time.Duration) RunOption {
+	return func(cfg *runConfig) {
+		cfg.reloadTimeout = d
+	}
+}
+
+// WithSecondSignal sets what Run does if a signal is received again while the lifecycle is stopping.
+// The function receives the components which are still stopping or draining. By default Run writes them to the logger and
+// exits the process with ExitAborted; nil makes Run ignore repeated signals.
+func WithSecondSignal(onSecond func(stopping []string)) RunOption {
+	return func(cfg *runConfig) {
+		cfg.onSecond = onSecond
+	}
+}
+
+// Run serves the lifecycle until a stop signal (see WithSignals) is received, ctx is done or a server fails,
+// then stops it, writes the resulting error to the logger and returns an exit code for os.Exit. A repeated signal
+// received while stopping is handled according to WithSecondSignal.
+func Run(ctx context.Context, lc *Lifecycle, opts ...RunOption) int {
+	cfg := &runConfig{
+		logger:        log.New(os.Stderr, "", 0),
+		signals:       components.NewSignals(),
+		reloadTimeout: DefaultReloadTimeout,
+	}
+	cfg.onSecond = func(stopping []string) {
+		cfg.logger.Printf("Aborted, still stopping: %s", strings.Join(stopping, ", "))
+		os.Exit(ExitAborted)
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var reloading int32
+	for _, sig := range cfg.reloadSignals {
+		cfg.signals.Handle(sig, func(os.Signal) {
+			if !atomic.CompareAndSwapInt32(&reloading, 0, 1) {
+				cfg.logger.Print("Reload is already in progress")
+				return
+			}
+			// Not in the goroutine listening signals: the stop signals have to be handled during the reload.
+			go func() {
+				defer atomic.StoreInt32(&reloading, 0)
+				reloadCtx, reloadCancel := context.WithTimeout(ctx, cfg.reloadTimeout)
+				defer reloadCancel()
+				if err := lc.Reload(reloadCtx); err != nil {
+					cfg.logger.Printf("Reloaded with error: %v", err)
+				}
+			}()
+		})
+	}
+	sigCtx, sigCancel := context.WithCancel(context.Background())
+	defer sigCancel()
+	triggers := cfg.signals.Listen(sigCtx)
+	go func() {
+		// The first signal stops the lifecycle gracefully.
+		if _, ok := <-triggers; !ok {
+			return
+		}
+		cancel()
+		if _, ok := <-triggers; ok && cfg.onSecond != nil {
+			cfg.onSecond(lc.stillStopping())
+		}
+	}()
+
+	err := lc.Serve(ctx)
+	if err != nil {
+		cfg.logger.Printf("Finished with error: %v", err)
+	}
+	return ExitCode(err)
+}
+
+// ExitCode classifies the error returned by Serve. A start failure has priority over a server failure,
+// which has priority over a stop timeout, because the latter are usually consequences of the former.
+func ExitCode(err error) int {
+	var (
+		startErr  *StartError
+		serverErr *ServerError
+	)
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.As(err, &startErr):
+		return ExitStartFailed
+	case errors.As(err, &serverErr):
+		return ExitServerFailed
+	case errors.Is(err, ErrStopTimeout):
+		return ExitStopTimeout
+	default:
+		return ExitFailure
+	}
+}
@@ -0,0 +1,117 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"go.uber.org/multierr"
+
+	"github.com/vivid-money/article-golang-di/pkg/components"
+)
+
+func TestExitCode(t *testing.T) {
+	startErr := &StartError{Err: errors.New("can't start db: connection refused")}
+	serverErr := &ServerError{Component: "http", Err: errors.New("listen: address already in use")}
+	stopTimeout := fmt.Errorf("db isn't stopped in time: %w", ErrStopTimeout)
+	other := errors.New("can't stop db: close failed")
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "no error", want: ExitOK},
+		{name: "start failure", err: startErr, want: ExitStartFailed},
+		{name: "server failure", err: serverErr, want: ExitServerFailed},
+		{name: "server panic", err: &ServerError{Component: "http", Err: &PanicError{Value: "boom"}}, want: ExitServerFailed},
+		{name: "stop timeout", err: stopTimeout, want: ExitStopTimeout},
+		{name: "other error", err: other, want: ExitFailure},
+		{name: "start failure and rollback timeout", err: multierr.Combine(startErr, stopTimeout), want: ExitStartFailed},
+		{name: "server failure after start failure", err: multierr.Combine(serverErr, startErr), want: ExitStartFailed},
+		{name: "server failure and stop timeout", err: multierr.Combine(stopTimeout, serverErr), want: ExitServerFailed},
+		{name: "stop timeout and other error", err: multierr.Combine(other, stopTimeout), want: ExitStopTimeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// stuckDrainer is a drainer whose stop never returns until the test releases it.
+type stuckDrainer struct {
+	release chan struct{}
+}
+
+func (d stuckDrainer) Connect(_ context.Context) error { return nil }
+
+func (d stuckDrainer) Drain(_ context.Context) error { return nil }
+
+func (d stuckDrainer) Stop(_ context.Context) error {
+	<-d.release
+	return nil
+}
+
+func TestRunSecondSignal(t *testing.T) {
+	tests := []struct {
+		name  string
+		await EventType // the second signal is sent after this event
+		want  string
+	}{
+		{name: "while draining", await: EventDrained, want: "http (draining for "},
+		{name: "while stopping", await: EventStopping, want: "http (for "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			awaited := make(chan struct{}, 1)
+			lc := New(WithDrainPeriod(500*time.Millisecond), WithObserver(ObserverFunc(func(e Event) {
+				if e.Type == tt.await {
+					awaited <- struct{}{}
+				}
+			})))
+			d := stuckDrainer{release: make(chan struct{})}
+			if err := lc.Add(d, WithName("http")); err != nil {
+				t.Fatal(err)
+			}
+			aborted := make(chan []string, 1)
+			exited := make(chan int, 1)
+			go func() {
+				exited <- Run(context.Background(), lc,
+					WithRunLogger(log.New(ioutil.Discard, "", 0)),
+					WithSignals(components.NewSignals(syscall.SIGUSR2)),
+					WithSecondSignal(func(stopping []string) { aborted <- stopping }),
+				)
+			}()
+			<-lc.startedCh
+
+			if err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR2); err != nil {
+				t.Fatal(err)
+			}
+			<-awaited
+			if err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR2); err != nil {
+				t.Fatal(err)
+			}
+			select {
+			case stopping := <-aborted:
+				if len(stopping) != 1 || !strings.HasPrefix(stopping[0], tt.want) {
+					t.Errorf("onSecond got %q, want %q...", stopping, tt.want)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("second signal isn't handled")
+			}
+
+			close(d.release)
+			if code := <-exited; code != ExitOK {
+				t.Errorf("exit code %d, want %d", code, ExitOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package lifecycle
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// State is a state of a component.
+type State string
+
+const (
+	StateRegistered State = "registered"
+	StateStarting   State = "starting"
+	StateRunning    State = "running"
+	StateDraining   State = "draining" // the drainer is notified and the component is waiting for the stop
+	StateStopping   State = "stopping"
+	StateStopped    State = "stopped"
+	StateFailed     State = "failed"
+)
+
+// ComponentState is a snapshot of the state of a component.
+type ComponentState struct {
+	Name  string   `json:"name"`
+	Roles []string `json:"roles"`
+	State State    `json:"state"`
+	// Since is the time of the last change of the state.
+	Since         time.Time     `json:"since"`
+	StartedAt     time.Time     `json:"-"` // omitted from JSON if zero, see MarshalJSON
+	StartDuration time.Duration `json:"-"` // a string like "1.5s" in JSON, see MarshalJSON
+	StopDuration  time.Duration `json:"-"`
+	Restarts      int           `json:"restarts"`
+	LastErr       error         `json:"-"`
+	LastError     string        `json:"last_error,omitempty"` // the text of LastErr, for JSON
+	Critical      bool          `json:"critical"`
+	Health        Health        `json:"health"`
+	CheckedAt     time.Time     `json:"-"`
+	HealthErr     error         `json:"-"`
+	HealthError   string        `json:"health_error,omitempty"` // the text of HealthErr, for JSON
+}
+
+// MarshalJSON renders the durations as strings and omits the times which haven't happened yet.
+func (s ComponentState) MarshalJSON() ([]byte, error) {
+	type plain ComponentState // without MarshalJSON, so it isn't called recursively
+	return json.Marshal(struct {
+		plain
+		StartedAt     *time.Time `json:"started_at,omitempty"`
+		StartDuration string     `json:"start_duration,omitempty"`
+		StopDuration  string     `json:"stop_duration,omitempty"`
+		CheckedAt     *time.Time `json:"checked_at,omitempty"`
+	}{
+		plain:         plain(s),
+		StartedAt:     timeOrNil(s.StartedAt),
+		StartDuration: durationOrEmpty(s.StartDuration),
+		StopDuration:  durationOrEmpty(s.StopDuration),
+		CheckedAt:     timeOrNil(s.CheckedAt),
+	})
+}
+
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func durationOrEmpty(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
+
+// Snapshot returns the states of all registered components in registration order.
+func (l *Lifecycle) Snapshot() []ComponentState {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	res := make([]ComponentState, 0, len(l.components))
+	for _, c := range l.components {
+		st := *l.states[c.name]
+		st.Roles = append([]string(nil), st.Roles...)
+		if st.LastErr != nil {
+			st.LastError = st.LastErr.Error()
+		}
+		if st.HealthErr != nil {
+			st.HealthError = st.HealthErr.Error()
+		}
+		res = append(res, st)
+	}
+	return res
+}
+
+func (c *component) roles() []string {
+	var res []string
+	if c.start != nil {
+		res = append(res, "starter")
+	}
+	if c.serve != nil {
+		res = append(res, "server")
+	}
+	if c.ready != nil {
+		res = append(res, "readier")
+	}
+	if c.stop != nil {
+		res = append(res, "shutdowner")
+	}
+	if c.reload != nil {
+		res = append(res, "reloader")
+	}
+	if c.check != nil {
+		res = append(res, "health_checker")
+	}
+	if c.drain != nil {
+		res = append(res, "drainer")
+	}
+	return res
+}
+
+// track updates the state of the component by the event. Must be called under l.mtx.
+func (l *Lifecycle) track(e Event) {
+	st, ok := l.states[e.Component]
+	if !ok {
+		return
+	}
+	now := time.Now()
+	set := func(state State) {
+		st.State = state
+		st.Since = now
+	}
+	if e.Err != nil {
+		st.LastErr = e.Err
+	}
+	switch e.Type {
+	case EventStarting:
+		set(StateStarting)
+	case EventStarted:
+		set(StateRunning)
+		st.StartedAt = now
+		st.StartDuration = e.Duration
+	case EventStartFailed:
+		set(StateFailed)
+		st.StartDuration = e.Duration
+	case EventDraining:
+		set(StateDraining)
+	case EventStopping:
+		set(StateStopping)
+	case EventStopped:
+		set(StateStopped)
+		st.StopDuration = e.Duration
+	case EventServerExited:
+		if st.State == StateStopping {
+			break
+		}
+		if e.Err != nil {
+			set(StateFailed)
+		} else {
+			set(StateStopped)
+		}
+	case EventRestarting:
+		set(StateStarting)
+		st.Restarts = e.Restarts
+	case EventRestarted:
+		set(StateRunning)
+		st.StartedAt = now
+	}
+}
+
+// Readiness returns nil if all components are running and all critical components are healthy, otherwise it
+// returns ErrNotReady naming the components which aren't. Readiness is turned off before the application is stopped.
+func (l *Lifecycle) Readiness() error {
+	l.mtx.Lock()
+	draining := l.draining
+	l.mtx.Unlock()
+	if draining {
+		return fmt.Errorf("%w: draining before stop", ErrNotReady)
+	}
+
+	var notRunning []string
+	for _, st := range l.Snapshot() {
+		switch {
+		case st.State != StateRunning:
+			notRunning = append(notRunning, fmt.Sprintf("%s is %s", st.Name, st.State))
+		case st.Critical && st.Health == HealthUnhealthy:
+			notRunning = append(notRunning, fmt.Sprintf("%s is unhealthy: %v", st.Name, st.HealthErr))
+		}
+	}
+	if len(notRunning) > 0 {
+		return fmt.Errorf("%w: %s", ErrNotReady, strings.Join(notRunning, ", "))
+	}
+	return nil
+}
+
+// stillStopping describes the components which are stopping or draining right now, like "a (for 1.5s)" or
+// "b (draining for 3s)".
+func (l *Lifecycle) stillStopping() []string {
+	var res []string
+	for _, st := range l.Snapshot() {
+		switch st.State {
+		case StateStopping:
+			res = append(res, fmt.Sprintf("%s (for %s)", st.Name, time.Since(st.Since).Round(time.Millisecond)))
+		case StateDraining:
+			res = append(res, fmt.Sprintf("%s (draining for %s)", st.Name, time.Since(st.Since).Round(time.Millisecond)))
+		}
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,63 @@
+package lifecycle
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestComponentStateJSON(t *testing.T) {
+	startedAt := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		state   ComponentState
+		present map[string]interface{}
+		absent  []string
+	}{
+		{
+			name:    "registered",
+			state:   ComponentState{Name: "db", State: StateRegistered, Since: startedAt},
+			present: map[string]interface{}{"name": "db", "state": "registered", "since": "2021-03-01T12:00:00Z"},
+			absent:  []string{"started_at", "start_duration", "stop_duration", "checked_at", "StartedAt"},
+		},
+		{
+			name: "stopped",
+			state: ComponentState{
+				Name:          "db",
+				State:         StateStopped,
+				StartedAt:     startedAt,
+				StartDuration: 1500 * time.Millisecond,
+				StopDuration:  20 * time.Millisecond,
+				CheckedAt:     startedAt.Add(time.Second),
+			},
+			present: map[string]interface{}{
+				"started_at":     "2021-03-01T12:00:00Z",
+				"start_duration": "1.5s",
+				"stop_duration":  "20ms",
+				"checked_at":     "2021-03-01T12:00:01Z",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.state)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got map[string]interface{}
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+			for key, want := range tt.present {
+				if got[key] != want {
+					t.Errorf("%s is %v, want %v in %s", key, got[key], want, data)
+				}
+			}
+			for _, key := range tt.absent {
+				if _, ok := got[key]; ok {
+					t.Errorf("%s is rendered in %s", key, data)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,352 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/multierr"
+)
+
+// Strategy defines which members of a Supervisor are restarted when one of them fails.
+type Strategy int
+
+const (
+	// OneForOne restarts only the failed member.
+	OneForOne Strategy = iota
+	// OneForAll restarts all members.
+	OneForAll
+	// RestForOne restarts the failed member and all members added after it, which usually depend on it.
+	RestForOne
+)
+
+func (s Strategy) String() string {
+	switch s {
+	case OneForOne:
+		return "one-for-one"
+	case OneForAll:
+		return "one-for-all"
+	case RestForOne:
+		return "rest-for-one"
+	default:
+		return "unknown"
+	}
+}
+
+const (
+	// DefaultSupervisorMaxRestarts is the number of restarts a Supervisor allows within DefaultSupervisorPeriod.
+	DefaultSupervisorMaxRestarts = 3
+	// DefaultSupervisorPeriod is the period DefaultSupervisorMaxRestarts is counted within.
+	DefaultSupervisorPeriod = 5 * time.Second
+)
+
+// ErrTooManyRestarts is returned by Supervisor.Serve when its members fail more often than it's allowed.
+var ErrTooManyRestarts = errors.New("too many restarts")
+
+// Supervisor is a group of components which are restarted together according to a Strategy when one of them fails.
+// The Supervisor is a component itself: it's added to a Lifecycle or to another Supervisor as a Server, Readier and
+// Shutdowner. Members are started in the order they are added and stopped in reverse order.
+// A member fails when its server exits, with or without an error, or when the health check of a critical member
+// fails: so a member without a server, like a DB connection, is restarted when it's unhealthy.
+// A Supervisor can be served only once.
+type Supervisor struct {
+	strategy    Strategy
+	maxRestarts int
+	period      time.Duration
+	memberOpts  []Option
+
+	members []*member
+	exits   chan *memberRun
+	ready   chan struct{}
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+	stopMtx  sync.Mutex
+	stopCtx  context.Context
+	stopErr  error
+	doneCh   chan struct{}
+}
+
+// SupervisorOption configures a Supervisor.
+type SupervisorOption func(s *Supervisor)
+
+// WithIntensity allows at most maxRestarts restarts of the members within the period. If they fail more often,
+// the Supervisor stops all of them and fails itself, so the failure is escalated to its parent.
+func WithIntensity(maxRestarts int, period time.Duration) SupervisorOption {
+	return func(s *Supervisor) {
+		s.maxRestarts = maxRestarts
+		s.period = period
+	}
+}
+
+// WithMemberOptions sets the options of the lifecycles each member is run by, e.g. observers.
+func WithMemberOptions(opts ...Option) SupervisorOption {
+	return func(s *Supervisor) {
+		s.memberOpts = append(s.memberOpts, opts...)
+	}
+}
+
+type member struct {
+	name     string
+	factory  func() (interface{}, error)
+	opts     []ComponentOption
+	restarts int
+	run      *memberRun
+}
+
+// memberRun is a single run of a member, every restart creates a new one.
+type memberRun struct {
+	m             *member
+	lc            *Lifecycle
+	err           error
+	exited        chan struct{}
+	stopRequested chan struct{}
+
+	unhealthyOnce sync.Once
+	unhealthyErr  error // the failed health check the run has been stopped by, set before the stop
+}
+
+func NewSupervisor(strategy Strategy, opts ...SupervisorOption) *Supervisor {
+	s := &Supervisor{
+		strategy:    strategy,
+		maxRestarts: DefaultSupervisorMaxRestarts,
+		period:      DefaultSupervisorPeriod,
+		exits:       make(chan *memberRun),
+		ready:       make(chan struct{}),
+		stopCh:      make(chan struct{}),
+		doneCh:      make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Add adds a member. The factory is called on every (re)start of the member and must return a new component,
+// which is registered in the lifecycle of the member like by Lifecycle.Add. Factories of dependent members may
+// use the components created by the factories of the members they depend on.
+func (s *Supervisor) Add(name string, factory func() (interface{}, error), opts ...ComponentOption) *Supervisor {
+	s.members = append(s.members, &member{name: name, factory: factory, opts: opts})
+	return s
+}
+
+// Ready returns a channel which is closed once all members are started for the first time.
+func (s *Supervisor) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// Serve starts all members and restarts them on failures until ctx is done, Stop is called or the restart
+// intensity is exceeded.
+func (s *Supervisor) Serve(ctx context.Context) error {
+	defer close(s.doneCh)
+
+	if err := s.startMembers(0); err != nil {
+		return multierr.Append(err, s.stopMembers(0))
+	}
+	close(s.ready)
+
+	var restarts []time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return s.stopMembers(0)
+		case <-s.stopCh:
+			// The errors are returned by Stop, so they aren't reported twice.
+			err := s.stopMembers(0)
+			s.stopMtx.Lock()
+			s.stopErr = err
+			s.stopMtx.Unlock()
+			return nil
+		case run := <-s.exits:
+			if run != run.m.run {
+				continue // the run has been replaced by a restart already
+			}
+			select {
+			case <-run.stopRequested:
+				continue
+			default:
+			}
+
+			cause := run.err
+			if cause == nil {
+				cause = fmt.Errorf("%s exited without an error", run.m.name)
+			}
+			// A failed restart is one more failure: it's retried until the intensity is exceeded.
+			for cause != nil {
+				now := time.Now()
+				restarts = append(restarts, now)
+				for len(restarts) > 0 && now.Sub(restarts[0]) > s.period {
+					restarts = restarts[1:]
+				}
+				if len(restarts) > s.maxRestarts {
+					err := fmt.Errorf("%w: %v", ErrTooManyRestarts, cause)
+					return multierr.Append(err, s.stopMembers(0))
+				}
+				cause = s.restart(run.m, cause)
+			}
+		}
+	}
+}
+
+// Stop stops all members in reverse order and waits until they are stopped or ctx is done.
+func (s *Supervisor) Stop(ctx context.Context) error {
+	s.stopOnce.Do(func() {
+		s.stopMtx.Lock()
+		s.stopCtx = ctx
+		s.stopMtx.Unlock()
+		close(s.stopCh)
+	})
+	select {
+	case <-s.doneCh:
+		s.stopMtx.Lock()
+		defer s.stopMtx.Unlock()
+		return s.stopErr
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// restart restarts the members affected by the failure of the member according to the strategy.
+func (s *Supervisor) restart(failed *member, cause error) error {
+	idx := 0
+	for i, m := range s.members {
+		if m == failed {
+			idx = i
+		}
+	}
+
+	from, to := idx, idx+1
+	switch s.strategy {
+	case OneForAll:
+		from, to = 0, len(s.members)
+	case RestForOne:
+		to = len(s.members)
+	}
+
+	// The failed member has already been stopped by its lifecycle, so stopMember skips it.
+	var err error
+	for i := to - 1; i >= from; i-- {
+		err = multierr.Append(err, s.stopMember(s.members[i]))
+	}
+	for i := from; i < to; i++ {
+		m := s.members[i]
+		m.restarts++
+		if startErr := s.startMember(m, cause); startErr != nil {
+			return multierr.Append(err, startErr)
+		}
+	}
+	return err
+}
+
+func (s *Supervisor) startMembers(from int) error {
+	for _, m := range s.members[from:] {
+		if err := s.startMember(m, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Supervisor) stopMembers(from int) error {
+	var err error
+	for i := len(s.members) - 1; i >= from; i-- {
+		err = multierr.Append(err, s.stopMember(s.members[i]))
+	}
+	return err
+}
+
+// startMember creates a new component of the member and runs it by its own lifecycle until it's started.
+// The cause is the error which has led to the restart of the member, if it's restarted. A member which has become
+// ready is started even if it fails right after that, during the start of its lifecycle: its failure is handled
+// by Serve like any other one.
+func (s *Supervisor) startMember(m *member, cause error) error {
+	becameReady := make(chan struct{})
+	var (
+		readyOnce sync.Once
+		lc        *Lifecycle
+		run       = &memberRun{m: m, exited: make(chan struct{}), stopRequested: make(chan struct{})}
+	)
+	lc = New(append(s.memberOpts, WithObserver(ObserverFunc(func(e Event) {
+		if e.Component != m.name {
+			return
+		}
+		switch {
+		case e.Type == EventStarted:
+			readyOnce.Do(func() { close(becameReady) })
+		case e.Type == EventHealthChanged && e.Err != nil && lc.Health() == HealthUnhealthy:
+			run.unhealthyOnce.Do(func() {
+				run.unhealthyErr = fmt.Errorf("%s is unhealthy: %w", m.name, e.Err)
+				go func() { _ = lc.Stop(context.Background()) }() // not here: Stop waits for this health check
+			})
+		}
+	})))...)
+	run.lc = lc
+	if m.restarts > 0 {
+		lc.notify(Event{Type: EventRestarting, Component: m.name, Err: cause, Restarts: m.restarts})
+	}
+	v, err := m.factory()
+	if err != nil {
+		return fmt.Errorf("can't create %s: %w", m.name, err)
+	}
+	if err := lc.Add(v, append([]ComponentOption{WithName(m.name)}, m.opts...)...); err != nil {
+		return err
+	}
+
+	m.run = run
+	go func() {
+		run.err = lc.Serve(context.Background())
+		if run.err == nil {
+			run.err = run.unhealthyErr
+		}
+		close(run.exited)
+		select {
+		case s.exits <- run:
+		case <-run.stopRequested:
+		}
+	}()
+
+	select {
+	case <-lc.startedCh:
+		return nil
+	case <-run.exited:
+		select {
+		case <-becameReady:
+			return nil
+		default:
+		}
+		close(run.stopRequested)
+		return run.err
+	}
+}
+
+func (s *Supervisor) stopMember(m *member) error {
+	run := m.run
+	if run == nil {
+		return nil
+	}
+	select {
+	case <-run.stopRequested:
+		return nil // already stopped
+	default:
+	}
+	close(run.stopRequested)
+	select {
+	case <-run.exited:
+		return nil // the member has already stopped by itself, its error is handled by Serve
+	default:
+	}
+
+	s.stopMtx.Lock()
+	ctx := s.stopCtx
+	s.stopMtx.Unlock()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := run.lc.Stop(ctx); err != nil {
+		return err
+	}
+	<-run.exited
+	return nil
+}
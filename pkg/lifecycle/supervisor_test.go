@@ -0,0 +1,261 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// crashingServer fails right after it has been launched, before its lifecycle has finished the start.
+type crashingServer struct{}
+
+func (crashingServer) Serve(_ context.Context) error { return errors.New("crash") }
+
+// healthyServer serves until it's stopped and reports every run.
+type healthyServer struct {
+	serving chan<- string
+	name    string
+}
+
+func (s healthyServer) Serve(ctx context.Context) error {
+	s.serving <- s.name
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// counter counts the components created by the factories of the members.
+type counter struct {
+	mtx     sync.Mutex
+	created map[string]int
+}
+
+func (c *counter) inc(name string) int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.created[name]++
+	return c.created[name]
+}
+
+func (c *counter) get(name string) int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.created[name]
+}
+
+// newCrashLoopSupervisor makes a supervisor of the members a, b and c, where b crashes right after the start
+// the first failures times.
+func newCrashLoopSupervisor(strategy Strategy, failures int) (*Supervisor, *counter, chan string) {
+	cnt := &counter{created: make(map[string]int)}
+	serving := make(chan string, 100)
+	factory := func(name string, failures int) func() (interface{}, error) {
+		return func() (interface{}, error) {
+			if cnt.inc(name) <= failures {
+				return crashingServer{}, nil
+			}
+			return healthyServer{serving: serving, name: name}, nil
+		}
+	}
+	s := NewSupervisor(strategy, WithIntensity(failures+1, time.Minute)).
+		Add("a", factory("a", 0)).
+		Add("b", factory("b", failures)).
+		Add("c", factory("c", 0))
+	return s, cnt, serving
+}
+
+func TestSupervisorRestartsCrashLoopingMember(t *testing.T) {
+	tests := []struct {
+		strategy Strategy
+		created  map[string]int
+	}{
+		{strategy: OneForOne, created: map[string]int{"a": 1, "b": 3, "c": 1}},
+		{strategy: OneForAll, created: map[string]int{"a": 3, "b": 3, "c": 3}},
+		{strategy: RestForOne, created: map[string]int{"a": 1, "b": 3, "c": 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy.String(), func(t *testing.T) {
+			s, cnt, serving := newCrashLoopSupervisor(tt.strategy, 2)
+			served := make(chan error, 1)
+			go func() { served <- s.Serve(context.Background()) }()
+
+			// Every healthy run of the members has to be served: all of them, except the two crashes of b.
+			want := tt.created["a"] + tt.created["b"] - 2 + tt.created["c"]
+			for got := 0; got < want; got++ {
+				select {
+				case <-serving:
+				case err := <-served:
+					t.Fatalf("supervisor has failed: %v", err)
+				case <-time.After(5 * time.Second):
+					t.Fatal("members aren't serving")
+				}
+			}
+			if err := s.Stop(context.Background()); err != nil {
+				t.Fatalf("can't stop: %v", err)
+			}
+			if err := <-served; err != nil {
+				t.Errorf("Serve returned %v", err)
+			}
+			for name, want := range tt.created {
+				if got := cnt.get(name); got != want {
+					t.Errorf("%s is created %d times, want %d", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSupervisorEscalatesCrashLoop(t *testing.T) {
+	for _, strategy := range []Strategy{OneForOne, OneForAll, RestForOne} {
+		t.Run(strategy.String(), func(t *testing.T) {
+			s, cnt, _ := newCrashLoopSupervisor(strategy, 1000)
+			s.maxRestarts = 3
+			err := s.Serve(context.Background())
+			if !errors.Is(err, ErrTooManyRestarts) {
+				t.Fatalf("expected ErrTooManyRestarts, got %v", err)
+			}
+			if got := cnt.get("b"); got != 4 {
+				t.Errorf("b is created %d times, want 4", got)
+			}
+		})
+	}
+}
+
+func TestSupervisorCountsFailedRestarts(t *testing.T) {
+	var mtx sync.Mutex
+	runs := 0
+	s := NewSupervisor(OneForOne, WithIntensity(2, time.Minute)).
+		Add("a", func() (interface{}, error) {
+			mtx.Lock()
+			defer mtx.Unlock()
+			if runs++; runs == 1 {
+				return crashingServer{}, nil
+			}
+			return nil, errors.New("can't connect")
+		})
+
+	err := s.Serve(context.Background())
+	if !errors.Is(err, ErrTooManyRestarts) {
+		t.Fatalf("expected ErrTooManyRestarts, got %v", err)
+	}
+	if runs != 3 {
+		t.Errorf("factory is called %d times, want 3", runs)
+	}
+}
+
+// finishingServer returns without an error right after the start.
+type finishingServer struct{}
+
+func (finishingServer) Serve(_ context.Context) error { return nil }
+
+func TestSupervisorRestartsMemberExitedWithoutError(t *testing.T) {
+	cnt := &counter{created: make(map[string]int)}
+	serving := make(chan string, 10)
+	s := NewSupervisor(OneForOne).
+		Add("worker", func() (interface{}, error) {
+			if cnt.inc("worker") == 1 {
+				return finishingServer{}, nil
+			}
+			return healthyServer{serving: serving, name: "worker"}, nil
+		})
+	served := make(chan error, 1)
+	go func() { served <- s.Serve(context.Background()) }()
+
+	select {
+	case <-serving:
+	case err := <-served:
+		t.Fatalf("supervisor has failed: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("worker isn't restarted")
+	}
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("can't stop: %v", err)
+	}
+	if err := <-served; err != nil {
+		t.Errorf("Serve returned %v", err)
+	}
+	if got := cnt.get("worker"); got != 2 {
+		t.Errorf("worker is created %d times, want 2", got)
+	}
+}
+
+// conn is a member without a server, like a DB connection, which is unhealthy until healthy is set.
+type conn struct {
+	healthy bool
+	checked chan<- string
+	name    string
+}
+
+func (c conn) Connect(_ context.Context) error { return nil }
+
+func (c conn) Check(_ context.Context) error {
+	if !c.healthy {
+		return errors.New("connection lost")
+	}
+	c.checked <- c.name
+	return nil
+}
+
+func TestSupervisorRestartsUnhealthyMember(t *testing.T) {
+	cnt := &counter{created: make(map[string]int)}
+	checked := make(chan string, 10)
+	factory := func(name string, failures int) func() (interface{}, error) {
+		return func() (interface{}, error) {
+			return conn{healthy: cnt.inc(name) > failures, checked: checked, name: name}, nil
+		}
+	}
+	s := NewSupervisor(RestForOne).
+		Add("config", factory("config", 0)).
+		Add("db", factory("db", 1)).
+		Add("repo", factory("repo", 0))
+	served := make(chan error, 1)
+	go func() { served <- s.Serve(context.Background()) }()
+
+	// Waits until the restarted db is healthy and repo, which depends on it, is restarted after it and checked.
+	dbHealthy := false
+	for !dbHealthy || cnt.get("repo") < 2 {
+		select {
+		case name := <-checked:
+			dbHealthy = dbHealthy || name == "db"
+		case err := <-served:
+			t.Fatalf("supervisor has failed: %v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("db isn't restarted, created %v", cnt.created)
+		}
+	}
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("can't stop: %v", err)
+	}
+	if err := <-served; err != nil {
+		t.Errorf("Serve returned %v", err)
+	}
+	want := map[string]int{"config": 1, "db": 2, "repo": 2}
+	for name, want := range want {
+		if got := cnt.get(name); got != want {
+			t.Errorf("%s is created %d times, want %d", name, got, want)
+		}
+	}
+}
+
+func TestSupervisorIgnoresNonCriticalUnhealthyMember(t *testing.T) {
+	cnt := &counter{created: make(map[string]int)}
+	s := NewSupervisor(OneForOne).
+		Add("cache", func() (interface{}, error) {
+			cnt.inc("cache")
+			return conn{}, nil
+		}, NonCritical())
+	served := make(chan error, 1)
+	go func() { served <- s.Serve(context.Background()) }()
+	<-s.Ready()
+	time.Sleep(50 * time.Millisecond) // the first health check is done right after the start
+
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("can't stop: %v", err)
+	}
+	if err := <-served; err != nil {
+		t.Errorf("Serve returned %v", err)
+	}
+	if got := cnt.get("cache"); got != 1 {
+		t.Errorf("non-critical cache is created %d times, want 1", got)
+	}
+}
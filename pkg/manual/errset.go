@@ -0,0 +1,211 @@
+package manual
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/multierr"
+)
+
+// ErrStoppedWithoutError is added to ErrSet when a server returns without an error: it's still a reason to stop.
+var ErrStoppedWithoutError = errors.New("component stopped without an error")
+
+// Phase is a phase of the component lifecycle an error has happened at.
+type Phase string
+
+const (
+	PhaseStart Phase = "start"
+	PhaseServe Phase = "serve"
+	PhaseStop  Phase = "stop"
+)
+
+// ComponentError is an error of a component at some phase, it can be found in ErrSet.Error() with errors.As.
+type ComponentError struct {
+	Component string
+	Phase     Phase
+	Time      time.Time
+	Err       error
+}
+
+func NewComponentError(component string, phase Phase, err error) *ComponentError {
+	return &ComponentError{
+		Component: component,
+		Phase:     phase,
+		Time:      time.Now(),
+		Err:       err,
+	}
+}
+
+func (e *ComponentError) Error() string {
+	return fmt.Sprintf("%s %q: %v", e.Phase, e.Component, e.Err)
+}
+
+func (e *ComponentError) Unwrap() error {
+	return e.Err
+}
+
+// Cause returns the root cause of the error, the innermost wrapped error.
+func (e *ComponentError) Cause() error {
+	return rootCause(e.Err)
+}
+
+// ErrContext is what a Classifier knows about the ErrSet when it classifies a new error.
+type ErrContext struct {
+	Stopping bool    // the intentional stop has begun, see ErrSet.Stopping
+	Previous []error // the errors added before, not suppressed ones
+}
+
+// Classifier reports whether the error is an expected consequence of a stop or of a previous error,
+// so it should be suppressed instead of being added to the result.
+type Classifier func(err error, ec ErrContext) bool
+
+// DefaultClassifiers are used by every ErrSet in addition to its own classifiers.
+var DefaultClassifiers = []Classifier{
+	IsCascadedCancellation,
+	IsServerClosed,
+	IsStoppedWithoutErrorWhileStopping,
+	IsDuplicate,
+}
+
+// IsCascadedCancellation suppresses context errors after another error or during the stop: they are caused by it.
+func IsCascadedCancellation(err error, ec ErrContext) bool {
+	return (ec.Stopping || len(ec.Previous) > 0) &&
+		(errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded))
+}
+
+// IsServerClosed suppresses http.ErrServerClosed, which is returned by a stopped http server.
+func IsServerClosed(err error, _ ErrContext) bool {
+	return errors.Is(err, http.ErrServerClosed)
+}
+
+// IsStoppedWithoutErrorWhileStopping suppresses ErrStoppedWithoutError after the intentional stop has begun.
+func IsStoppedWithoutErrorWhileStopping(err error, ec ErrContext) bool {
+	return ec.Stopping && errors.Is(err, ErrStoppedWithoutError)
+}
+
+// IsDuplicate suppresses an error of a component which has the same root cause as a previous error of the same
+// component, wrapped differently. Errors of different components aren't duplicates even with the same cause,
+// e.g. ErrShutdownTimeout, and errors added without a component aren't duplicates at all.
+func IsDuplicate(err error, ec ErrContext) bool {
+	for _, prev := range ec.Previous {
+		if errors.Is(err, rootCause(prev)) && sameComponent(err, prev) {
+			return true
+		}
+	}
+	return false
+}
+
+func sameComponent(a, b error) bool {
+	var ca, cb *ComponentError
+	return errors.As(a, &ca) && errors.As(b, &cb) && ca.Component == cb.Component
+}
+
+func rootCause(err error) error {
+	for next := errors.Unwrap(err); next != nil; next = errors.Unwrap(err) {
+		err = next
+	}
+	return err
+}
+
+// ErrSet collects errors of the application start and stop, it's safe for concurrent use.
+type ErrSet struct {
+	mtx         sync.Mutex
+	classifiers []Classifier
+	stopping    bool
+	errs        []error
+	suppressed  []error
+}
+
+// NewErrSet creates an ErrSet which suppresses errors by the classifiers in addition to DefaultClassifiers.
+// The zero ErrSet uses only DefaultClassifiers.
+func NewErrSet(classifiers ...Classifier) *ErrSet {
+	return &ErrSet{classifiers: classifiers}
+}
+
+func (s *ErrSet) Add(err error) {
+	if err == nil {
+		return
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	ec := ErrContext{Stopping: s.stopping, Previous: s.errs}
+	for _, classifiers := range [][]Classifier{DefaultClassifiers, s.classifiers} {
+		for _, isExpected := range classifiers {
+			if isExpected(err, ec) {
+				s.suppressed = append(s.suppressed, err)
+				return
+			}
+		}
+	}
+
+	s.errs = append(s.errs, err)
+}
+
+// AddFor adds an error of the component at the phase.
+func (s *ErrSet) AddFor(component string, phase Phase, err error) {
+	if err == nil {
+		return
+	}
+	s.Add(NewComponentError(component, phase, err))
+}
+
+// Stopping marks that the intentional stop has begun, so errors caused by it are expected.
+func (s *ErrSet) Stopping() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.stopping = true
+}
+
+func (s *ErrSet) Error() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return multierr.Combine(s.errs...)
+}
+
+// Primary returns the first added error, the one that has triggered the shutdown.
+func (s *ErrSet) Primary() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if len(s.errs) == 0 {
+		return nil
+	}
+	return s.errs[0]
+}
+
+// Secondary returns the errors added after the primary one, usually a fallout of the shutdown.
+func (s *ErrSet) Secondary() []error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if len(s.errs) < 2 {
+		return nil
+	}
+	return append([]error(nil), s.errs[1:]...)
+}
+
+// Suppressed returns the errors which were classified as expected and aren't included into Error().
+func (s *ErrSet) Suppressed() []error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return append([]error(nil), s.suppressed...)
+}
+
+// DebugString describes both the resulting and the suppressed errors.
+func (s *ErrSet) DebugString() string {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	var b strings.Builder
+	b.WriteString("errors:")
+	for _, err := range s.errs {
+		b.WriteString("\n  " + err.Error())
+	}
+	b.WriteString("\nsuppressed:")
+	for _, err := range s.suppressed {
+		b.WriteString("\n  " + err.Error())
+	}
+	return b.String()
+}
@@ -0,0 +1,169 @@
+package manual
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+var errConnRefused = errors.New("connection refused")
+
+func TestErrSetClassifiers(t *testing.T) {
+	tests := []struct {
+		name       string
+		stopping   bool
+		previous   []error
+		err        error
+		suppressed bool
+	}{
+		{name: "first error", err: errConnRefused},
+		{name: "cancellation as the first error", err: context.Canceled},
+		{name: "cancellation after an error", previous: []error{errConnRefused}, err: context.Canceled, suppressed: true},
+		{name: "cancellation while stopping", stopping: true, err: fmt.Errorf("serve: %w", context.Canceled), suppressed: true},
+		{name: "deadline while stopping", stopping: true, err: context.DeadlineExceeded, suppressed: true},
+		{name: "server closed", err: fmt.Errorf("http: %w", http.ErrServerClosed), suppressed: true},
+		{name: "stopped without error", err: ErrStoppedWithoutError},
+		{name: "stopped without error while stopping", stopping: true, err: ErrStoppedWithoutError, suppressed: true},
+		{
+			name:       "same component, same cause",
+			previous:   []error{NewComponentError("db", PhaseServe, errConnRefused)},
+			err:        NewComponentError("db", PhaseStop, fmt.Errorf("close: %w", errConnRefused)),
+			suppressed: true,
+		},
+		{
+			name:     "other component, same cause",
+			previous: []error{NewComponentError("db", PhaseStop, ErrShutdownTimeout)},
+			err:      NewComponentError("http", PhaseStop, ErrShutdownTimeout),
+		},
+		{
+			name:     "same component, other cause",
+			previous: []error{NewComponentError("db", PhaseServe, errConnRefused)},
+			err:      NewComponentError("db", PhaseStop, ErrShutdownTimeout),
+		},
+		{
+			name:     "plain error after a component error with the same cause",
+			previous: []error{NewComponentError("db", PhaseServe, errConnRefused)},
+			err:      fmt.Errorf("ping: %w", errConnRefused),
+		},
+		{
+			name:     "component error after a plain error with the same cause",
+			previous: []error{errConnRefused},
+			err:      NewComponentError("db", PhaseServe, errConnRefused),
+		},
+		{name: "plain errors with the same cause", previous: []error{errConnRefused}, err: errConnRefused},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewErrSet()
+			for _, prev := range tt.previous {
+				s.Add(prev)
+			}
+			if tt.stopping {
+				s.Stopping()
+			}
+			s.Add(tt.err)
+
+			suppressed := s.Suppressed()
+			if got := len(suppressed) == 1 && suppressed[0] == tt.err; got != tt.suppressed {
+				t.Errorf("suppressed: %v, want %v; %s", got, tt.suppressed, s.DebugString())
+			}
+			if got := errors.Is(s.Error(), tt.err); got == tt.suppressed {
+				t.Errorf("error is in Error(): %v, want %v; %s", got, !tt.suppressed, s.DebugString())
+			}
+		})
+	}
+}
+
+func TestErrSetCustomClassifiers(t *testing.T) {
+	errRetry := errors.New("retry later")
+	s := NewErrSet(func(err error, ec ErrContext) bool {
+		return errors.Is(err, errRetry) && len(ec.Previous) > 0
+	})
+	s.Add(errRetry) // the first one isn't suppressed by the custom classifier
+	s.Add(fmt.Errorf("again: %w", errRetry))
+	s.Add(fmt.Errorf("http: %w", http.ErrServerClosed)) // the default classifiers are still used
+
+	if errs := s.Error(); errs != errRetry {
+		t.Errorf("unexpected Error(): %v", errs)
+	}
+	if n := len(s.Suppressed()); n != 2 {
+		t.Errorf("%d errors are suppressed, want 2", n)
+	}
+}
+
+func TestErrSetDebugString(t *testing.T) {
+	var s ErrSet
+	s.AddFor("db", PhaseStart, errConnRefused)
+	s.AddFor("http", PhaseServe, http.ErrServerClosed)
+	s.AddFor("cache", PhaseStop, nil)
+
+	want := strings.Join([]string{
+		"errors:",
+		`  start "db": connection refused`,
+		"suppressed:",
+		`  serve "http": http: Server closed`,
+	}, "\n")
+	if got := s.DebugString(); got != want {
+		t.Errorf("DebugString() = %q, want %q", got, want)
+	}
+}
+
+func TestErrSetPrimaryAndSecondary(t *testing.T) {
+	var s ErrSet
+	if s.Primary() != nil || s.Secondary() != nil {
+		t.Fatal("empty ErrSet has errors")
+	}
+
+	s.Stopping()
+	s.AddFor("http", PhaseServe, http.ErrServerClosed)                 // suppressed before the primary error
+	s.AddFor("worker", PhaseServe, context.Canceled)                   // suppressed too
+	s.AddFor("db", PhaseStop, fmt.Errorf("close: %w", errConnRefused)) // the primary one
+	s.AddFor("cache", PhaseStop, ErrShutdownTimeout)
+	s.AddFor("queue", PhaseStop, ErrShutdownTimeout)
+
+	var primary *ComponentError
+	if !errors.As(s.Primary(), &primary) || primary.Component != "db" {
+		t.Fatalf("unexpected primary error: %v", s.Primary())
+	}
+	var components []string
+	for _, err := range s.Secondary() {
+		var ce *ComponentError
+		if !errors.As(err, &ce) {
+			t.Fatalf("secondary error isn't ComponentError: %v", err)
+		}
+		components = append(components, ce.Component)
+	}
+	if strings.Join(components, ",") != "cache,queue" {
+		t.Errorf("unexpected secondary errors of %v", components)
+	}
+	if n := len(s.Suppressed()); n != 2 {
+		t.Errorf("%d errors are suppressed, want 2", n)
+	}
+}
+
+func TestErrSetErrorAs(t *testing.T) {
+	var s ErrSet
+	s.AddFor("db", PhaseStart, fmt.Errorf("connect: %w", errConnRefused))
+	s.AddFor("http", PhaseStop, ErrShutdownTimeout)
+
+	err := s.Error()
+	var ce *ComponentError
+	if !errors.As(err, &ce) {
+		t.Fatalf("ComponentError isn't found in %v", err)
+	}
+	if ce.Component != "db" || ce.Phase != PhaseStart || ce.Time.IsZero() {
+		t.Errorf("unexpected first ComponentError: %+v", ce)
+	}
+	if ce.Cause() != errConnRefused {
+		t.Errorf("Cause() = %v, want %v", ce.Cause(), errConnRefused)
+	}
+	if !errors.Is(err, errConnRefused) || !errors.Is(err, ErrShutdownTimeout) {
+		t.Errorf("the causes aren't found in %v", err)
+	}
+	if want := `start "db": connect: connection refused; stop "http": shutdown timeout`; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
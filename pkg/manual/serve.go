@@ -0,0 +1,211 @@
+// Package manual provides the helpers for starting and stopping the application components manually, in the plain
+// code: ErrSet collects the errors, Serve and ServeContext start a server in a separate goroutine and Shutdown stops
+// a component with a deadline.
+package manual
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/multierr"
+
+	"github.com/vivid-money/article-golang-di/pkg/internal/recovery"
+)
+
+// DefaultShutdownTimeout is a reasonable timeout for Shutdown.
+const DefaultShutdownTimeout = 15 * time.Second
+
+// ErrShutdownTimeout is added to ErrSet by Shutdown when a component isn't stopped in time.
+var ErrShutdownTimeout = errors.New("shutdown timeout")
+
+// PanicError is a panic recovered in a server or a shutdown function, it's added to ErrSet like an ordinary error.
+type PanicError = recovery.PanicError
+
+// CatchPanic wraps the function of a component so that a panic in it is returned as *PanicError. Serve and Shutdown
+// use it themselves, it's for the goroutines started by hand, e.g. in fx hooks.
+func CatchPanic(fn func(ctx context.Context) error) func(ctx context.Context) error {
+	return recovery.Catch(fn)
+}
+
+// Handle controls a server started by Serve or ServeContext.
+type Handle struct {
+	ctx           context.Context
+	cancelServer  context.CancelFunc // cancels the server's context, nil for Serve
+	stop          func(ctx context.Context) error
+	terminateOnce sync.Once // the server is terminated only once, whichever path comes first
+	done          chan struct{}
+	err           error
+}
+
+// Serve starts the server in a separate goroutine and returns its handle. The server's result is added to errSet:
+// a server returning without an error is still a reason to stop the application, so ErrStoppedWithoutError is added.
+// The server gets the background context, so it's stopped only by the stop function called by Handle.Stop.
+// If ctx is already done, the server isn't started, so the application start can be interrupted in the middle when
+// one of the previously started servers has exited.
+func Serve(
+	ctx context.Context,
+	name string,
+	errSet *ErrSet,
+	server func(ctx context.Context) error,
+	stop func(ctx context.Context) error,
+) (*Handle, error) {
+	return serve(ctx, context.Background(), nil, name, errSet, server, stop)
+}
+
+// ServeContext is like Serve, but the server gets a context with the values of ctx, which is cancelled when ctx is
+// done. So the server is stopped either by the parent's cancellation or by Handle.Stop, which calls the stop
+// function or, if it's nil, cancels the server's context. Only the path which comes first terminates the server,
+// so a server which stops itself on the context cancellation doesn't need the stop function. The server's result
+// after the cancellation is an expected one and marks errSet as stopping.
+func ServeContext(
+	ctx context.Context,
+	name string,
+	errSet *ErrSet,
+	server func(ctx context.Context) error,
+	stop func(ctx context.Context) error,
+) (*Handle, error) {
+	// The parent's cancellation isn't propagated as is: it has to race with Handle.Stop under terminateOnce.
+	serverCtx, cancelServer := context.WithCancel(valuesOnly{ctx})
+	h, err := serve(ctx, serverCtx, cancelServer, name, errSet, server, stop)
+	if err != nil {
+		cancelServer()
+		return nil, err
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+			h.terminateOnce.Do(cancelServer)
+		case <-h.done:
+		}
+	}()
+	return h, nil
+}
+
+// valuesOnly is a context with the values of the parent which is never done itself.
+type valuesOnly struct {
+	context.Context
+}
+
+func (valuesOnly) Deadline() (time.Time, bool) { return time.Time{}, false }
+
+func (valuesOnly) Done() <-chan struct{} { return nil }
+
+func (valuesOnly) Err() error { return nil }
+
+func serve(
+	ctx, serverCtx context.Context,
+	cancelServer context.CancelFunc,
+	name string,
+	errSet *ErrSet,
+	server func(ctx context.Context) error,
+	stop func(ctx context.Context) error,
+) (*Handle, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	h := &Handle{
+		ctx:          ctx,
+		cancelServer: cancelServer,
+		stop:         stop,
+		done:         make(chan struct{}),
+	}
+	go func() {
+		err := recovery.Catch(server)(serverCtx) // a panic is recorded and stops the application as an error
+		if serverCtx.Err() != nil {
+			errSet.Stopping() // the server has been stopped intentionally, by the parent context or Handle.Stop
+		}
+		if err != nil {
+			errSet.AddFor(name, PhaseServe, err)
+		} else {
+			errSet.AddFor(name, PhaseServe, ErrStoppedWithoutError)
+		}
+		h.err = err
+		close(h.done)
+		cancel() // even if the server has returned without an error, the whole application should be stopped
+		if cancelServer != nil {
+			h.terminateOnce.Do(cancelServer) // releases the context of the server which has returned by itself
+		}
+	}()
+
+	return h, nil
+}
+
+// Context returns a context which is done when the parent context is done or the server has returned.
+func (h *Handle) Context() context.Context {
+	return h.ctx
+}
+
+// Done returns a channel which is closed when the server has returned.
+func (h *Handle) Done() <-chan struct{} {
+	return h.done
+}
+
+// Err returns the error the server has returned with, it's nil until Done is closed.
+func (h *Handle) Err() error {
+	select {
+	case <-h.done:
+		return h.err
+	default:
+		return nil
+	}
+}
+
+// Stop calls the stop function or, for ServeContext without it, cancels the server's context and waits until
+// the server has returned or ctx is done. Nothing is called for a server which has already returned or has been
+// stopped by the parent's context, so the server is terminated exactly once.
+func (h *Handle) Stop(ctx context.Context) error {
+	var err error
+	h.terminateOnce.Do(func() {
+		select {
+		case <-h.done:
+			return
+		default:
+		}
+		if h.stop != nil {
+			err = h.stop(ctx)
+		} else if h.cancelServer != nil {
+			h.cancelServer()
+		}
+	})
+	select {
+	case <-h.done:
+		return err
+	case <-ctx.Done():
+		return multierr.Append(err, ctx.Err())
+	}
+}
+
+// Shutdown marks errSet as stopping, calls the shutdown function and adds its error to errSet. The shutdown function
+// gets a context with the timeout and isn't waited for after it: then ErrShutdownTimeout is added instead.
+// A non-positive timeout means no deadline.
+func Shutdown(name string, errSet *ErrSet, shutdown func(ctx context.Context) error, timeout time.Duration) {
+	errSet.Stopping() // from now on the errors caused by the stop itself are expected and are suppressed by ErrSet
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- recovery.Catch(shutdown)(ctx)
+	}()
+	// Not the context error itself: it would be suppressed as an expected one.
+	timeoutErr := fmt.Errorf("%w after %s", ErrShutdownTimeout, timeout)
+	var err error
+	select {
+	case err = <-done:
+		if err != nil && ctx.Err() != nil {
+			err = timeoutErr // the shutdown function has given up because of the deadline
+		}
+	case <-ctx.Done():
+		err = timeoutErr
+	}
+	errSet.AddFor(name, PhaseStop, err)
+}
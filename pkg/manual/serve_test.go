@@ -0,0 +1,182 @@
+package manual
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testServer counts the terminations by both paths: by the context of the server and by its stop function.
+type testServer struct {
+	terminations int32
+	stopOnce     sync.Once
+	stopCh       chan struct{}
+}
+
+func newTestServer() *testServer {
+	return &testServer{stopCh: make(chan struct{})}
+}
+
+func (s *testServer) Serve(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		atomic.AddInt32(&s.terminations, 1)
+		return ctx.Err()
+	case <-s.stopCh:
+		return nil
+	}
+}
+
+func (s *testServer) Stop(_ context.Context) error {
+	atomic.AddInt32(&s.terminations, 1)
+	s.stopOnce.Do(func() { close(s.stopCh) })
+	return nil
+}
+
+func (s *testServer) assertTerminatedOnce(t *testing.T) {
+	t.Helper()
+	if n := atomic.LoadInt32(&s.terminations); n != 1 {
+		t.Errorf("the server is terminated %d times", n)
+	}
+}
+
+func waitDone(t *testing.T, h *Handle) {
+	t.Helper()
+	select {
+	case <-h.Done():
+	case <-time.After(time.Second):
+		t.Fatal("the server isn't stopped")
+	}
+}
+
+func TestServeContextStoppedByParent(t *testing.T) {
+	for _, withStop := range []bool{false, true} {
+		srv := newTestServer()
+		var stop func(ctx context.Context) error
+		if withStop {
+			stop = srv.Stop
+		}
+		parent, cancel := context.WithCancel(context.Background())
+		errSet := &ErrSet{}
+		h, err := ServeContext(parent, "srv", errSet, srv.Serve, stop)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cancel()
+		waitDone(t, h)
+		if err := h.Stop(context.Background()); err != nil {
+			t.Errorf("stop failed: %v", err)
+		}
+		srv.assertTerminatedOnce(t)
+		if err := errSet.Error(); err != nil {
+			t.Errorf("the stop by the parent is an error: %v", err)
+		}
+	}
+}
+
+func TestServeContextStoppedByHandle(t *testing.T) {
+	for _, withStop := range []bool{false, true} {
+		srv := newTestServer()
+		var stop func(ctx context.Context) error
+		if withStop {
+			stop = srv.Stop
+		}
+		parent, cancel := context.WithCancel(context.Background())
+		errSet := &ErrSet{}
+		h, err := ServeContext(parent, "srv", errSet, srv.Serve, stop)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		Shutdown("srv", errSet, h.Stop, time.Second)
+		cancel()
+		waitDone(t, h)
+		srv.assertTerminatedOnce(t)
+		if err := errSet.Error(); err != nil {
+			t.Errorf("the stop by the handle is an error: %v", err)
+		}
+	}
+}
+
+func TestServeContextConcurrentStops(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		srv := newTestServer()
+		parent, cancel := context.WithCancel(context.Background())
+		h, err := ServeContext(parent, "srv", &ErrSet{}, srv.Serve, srv.Stop)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		go cancel()
+		if err := h.Stop(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		waitDone(t, h)
+		srv.assertTerminatedOnce(t)
+	}
+}
+
+func TestServeContextPassesValues(t *testing.T) {
+	type key struct{}
+	parent := context.WithValue(context.Background(), key{}, "value")
+	got := make(chan interface{}, 1)
+	h, err := ServeContext(parent, "srv", &ErrSet{}, func(ctx context.Context) error {
+		got <- ctx.Value(key{})
+		<-ctx.Done()
+		return nil
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := <-got; v != "value" {
+		t.Errorf("got %v", v)
+	}
+	_ = h.Stop(context.Background())
+}
+
+func TestServeStopsOnce(t *testing.T) {
+	srv := newTestServer()
+	h, err := Serve(context.Background(), "srv", &ErrSet{}, srv.Serve, srv.Stop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = h.Stop(context.Background())
+	_ = h.Stop(context.Background())
+	srv.assertTerminatedOnce(t)
+}
+
+func TestServeReportsServerFailure(t *testing.T) {
+	failure := errors.New("crash")
+	errSet := &ErrSet{}
+	h, err := Serve(context.Background(), "srv", errSet, func(context.Context) error { return failure }, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-h.Context().Done():
+	case <-time.After(time.Second):
+		t.Fatal("the context isn't cancelled after the failure")
+	}
+	if !errors.Is(h.Err(), failure) || !errors.Is(errSet.Primary(), failure) {
+		t.Errorf("unexpected errors: %v, %v", h.Err(), errSet.Primary())
+	}
+	var compErr *ComponentError
+	if !errors.As(errSet.Error(), &compErr) || compErr.Component != "srv" || compErr.Phase != PhaseServe {
+		t.Errorf("unexpected component error: %v", errSet.Error())
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	errSet := &ErrSet{}
+	Shutdown("slow", errSet, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, 10*time.Millisecond)
+	if !errors.Is(errSet.Error(), ErrShutdownTimeout) {
+		t.Errorf("expected ErrShutdownTimeout, got %v", errSet.Error())
+	}
+}
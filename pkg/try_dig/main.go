@@ -7,6 +7,7 @@ import (
 	"go.uber.org/dig"
 
 	"github.com/vivid-money/article-golang-di/pkg/components"
+	"github.com/vivid-money/article-golang-di/pkg/fakedb"
 )
 
 func main() {
@@ -21,6 +22,7 @@ func main() {
 		logger.Print("Provided logger")
 		return logger // Прокинули уже созданный логгер.
 	})
+	_ = container.Provide(fakedb.Config) // настройки базы данных - тоже зависимость
 	_ = container.Provide(components.NewDBConn)
 	_ = container.Provide(components.NewHTTPServer)
 
@@ -12,6 +12,8 @@ import (
 	"net/http"
 
 	"github.com/vivid-money/article-golang-di/pkg/components"
+	"github.com/vivid-money/article-golang-di/pkg/fakedb"
+	"github.com/vivid-money/article-golang-di/pkg/manual"
 )
 
 func main() {
@@ -30,7 +32,7 @@ func main() {
 		}),
 		fx.Provide(
 			func(logger components.Logger, lc fx.Lifecycle) *components.DBConn { // можем получить ещё и lc - жизненный цикл.
-				conn := components.NewDBConn(logger)
+				conn := components.NewDBConn(logger, fakedb.Config())
 				// Можно навесить хуки.
 				lc.Append(fx.Hook{
 					OnStart: func(ctx context.Context) error {
@@ -52,7 +54,8 @@ func main() {
 						go func() {
 							defer cancel()
 							// Ассинхронно запускаем сервер, т.к. Serve - блокирующая операция.
-							if err := s.Serve(context.Background()); err != nil && !errors.Is(err, http.ErrServerClosed) {
+							// Паника в нём превратится в ошибку, а cancel аккуратно остановит приложение.
+							if err := manual.CatchPanic(s.Serve)(context.Background()); err != nil && !errors.Is(err, http.ErrServerClosed) {
 								logger.Print("Error: ", err)
 							}
 						}()
@@ -77,7 +80,11 @@ func main() {
 		fx.NopLogger,
 	)
 
-	_ = app.Start(ctx)
+	if err := app.Start(ctx); err != nil {
+		// fx уже остановил те компоненты, которые успели запуститься, остаётся только сообщить об ошибке.
+		logger.Print("Can't start the app: ", err)
+		return
+	}
 
 	<-ctx.Done() // ожидаем завершения контекста в случае ошибки или получения сигнала
 
@@ -12,6 +12,7 @@ import (
 	"net/http"
 
 	"github.com/vivid-money/article-golang-di/pkg/components"
+	"github.com/vivid-money/article-golang-di/pkg/fakedb"
 )
 
 func main() {
@@ -23,7 +24,7 @@ func main() {
 
 	g, gCtx := errgroup.WithContext(ctx)
 
-	dbConn := components.NewDBConn(logger)
+	dbConn := components.NewDBConn(logger, fakedb.Config())
 	g.Go(func() error {
 		// dbConn умеет останавливаться по отмене контекста.
 		if err := dbConn.Connect(gCtx); err != nil {
@@ -2,14 +2,12 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"log"
 	"os"
 
-	"errors"
-	"net/http"
-
 	"github.com/vivid-money/article-golang-di/pkg/components"
+	"github.com/vivid-money/article-golang-di/pkg/fakedb"
+	"github.com/vivid-money/article-golang-di/pkg/manual"
 )
 
 func main() {
@@ -24,11 +22,13 @@ func main() {
 		cancel()
 	}()
 
-	errset := &ErrSet{}
+	errset := &manual.ErrSet{}
 
 	errset.Add(runApp(ctx, logger, errset))
 
-	_ = errset.Error() // можно обработать ошибку
+	_ = errset.Error()      // можно обработать ошибку
+	_ = errset.Primary()    // или только ту, из-за которой приложение завершилось
+	_ = errset.Suppressed() // а ожидаемые ошибки остановки видны отдельно, например, в errset.DebugString()
 	/*
 		Output:
 		---
@@ -46,20 +46,21 @@ func main() {
 	*/
 }
 
-func runApp(ctx context.Context, logger components.Logger, errSet *ErrSet) error {
-	var err error
-
-	dbConn := components.NewDBConn(logger)
+func runApp(ctx context.Context, logger components.Logger, errSet *manual.ErrSet) error {
+	dbConn := components.NewDBConn(logger, fakedb.Config())
 	if err := dbConn.Connect(ctx); err != nil {
-		return fmt.Errorf("cant connect dbConn: %w", err)
+		return manual.NewComponentError("dbConn", manual.PhaseStart, err)
 	}
-	defer Shutdown("dbConn", errSet, dbConn.Stop)
+	defer manual.Shutdown("dbConn", errSet, dbConn.Stop, manual.DefaultShutdownTimeout)
 
 	httpServer := components.NewHTTPServer(logger, dbConn)
-	if ctx, err = Serve(ctx, "httpServer", errSet, httpServer.Serve); err != nil && !errors.Is(err, http.ErrServerClosed) {
-		return fmt.Errorf("cant serve httpServer: %w", err)
+	srv, err := manual.Serve(ctx, "httpServer", errSet, httpServer.Serve, httpServer.Stop)
+	if err != nil {
+		return manual.NewComponentError("httpServer", manual.PhaseStart, err)
 	}
-	defer Shutdown("httpServer", errSet, httpServer.Stop)
+	// srv.Stop не только останавливает сервер, но и дожидается завершения его Serve
+	defer manual.Shutdown("httpServer", errSet, srv.Stop, manual.DefaultShutdownTimeout)
+	ctx = srv.Context() // этот контекст завершится и при падении сервера
 
 	components.AwaitSignal(ctx)
 	return ctx.Err()
@@ -5,13 +5,14 @@ import (
 	"os"
 
 	"github.com/vivid-money/article-golang-di/pkg/components"
+	"github.com/vivid-money/article-golang-di/pkg/fakedb"
 )
 
 func doSomething(_ interface{}) {}
 
 func simpleExampleA() {
 	logger := log.New(os.Stderr, "", 0)
-	dbConn := components.NewDBConn(logger)
+	dbConn := components.NewDBConn(logger, fakedb.Config())
 	httpServer := components.NewHTTPServer(logger, dbConn)
 	doSomething(httpServer)
 }
@@ -6,6 +6,8 @@ import (
 	"os"
 
 	"github.com/vivid-money/article-golang-di/pkg/components"
+	"github.com/vivid-money/article-golang-di/pkg/fakedb"
+	"github.com/vivid-money/article-golang-di/pkg/lifecycle"
 )
 
 func main() {
@@ -15,22 +17,50 @@ func main() {
 	logger := log.New(os.Stderr, "", 0)
 	logger.Print("Started")
 
-	lc := lifecycle.NewLifecycle()
+	lc := lifecycle.New()
 
-	dbConn := components.NewDBConn(logger)
-	lc.AddServer(func(ctx context.Context) error { // просто регистриуем в правильном порядке серверы и шатдаунеры
-		return dbConn.Connect(ctx)
-	}).AddShutdowner(func(ctx context.Context) error {
-		return dbConn.Stop(ctx)
-	})
+	// Служебный сервер регистрируем первым: пробы и /debug/lifecycle доступны всё время запуска и остановки.
+	adminSrv := components.NewAdminHTTPServer(logger, "127.0.0.1:3001")
+	lifecycle.RegisterAdmin(adminSrv, lc) // /healthz, /readyz и /debug/lifecycle для проб и отладки
+	if err := lc.Add(adminSrv, lifecycle.WithName("AdminHTTPServer")); err != nil {
+		logger.Fatal(err)
+	}
 
-	httpSrv := components.NewHTTPServer(logger, dbConn)
-	lc.Add(httpSrv) // потому что httpSrv реализует интерфейсы Server и Shutdowner
+	// Просто регистрируем компоненты в правильном порядке, lifecycle сам определит, какие интерфейсы они реализуют.
+	dbConn := components.NewDBConn(logger, fakedb.Config())
+	if err := lc.Add(dbConn); err != nil { // dbConn реализует интерфейсы Connector и Shutdowner
+		logger.Fatal(err)
+	}
 
-	go func() {
-		components.AwaitSignal(ctx)
-		lc.Stop(context.Background())
-	}()
+	httpSrv := components.NewHTTPServer(logger, dbConn)
+	if err := lc.Add(httpSrv); err != nil { // потому что httpSrv реализует интерфейсы Server и Shutdowner
+		logger.Fatal(err)
+	}
 
-	_ = lc.Serve(ctx)
+	// Run сам дождётся сигнала или падения одного из серверов, остановит все компоненты и вернёт код выхода.
+	code := lifecycle.Run(ctx, lc, lifecycle.WithRunLogger(logger))
+	cancel() // os.Exit не вызывает отложенные функции
+	os.Exit(code)
+	/*
+		Output:
+		---
+		Started
+		New AdminHTTPServer
+		New DBConn
+		New HTTPServer
+		Serving AdminHTTPServer
+		Connecting DBConn
+		Connected DBConn
+		Serving HTTPServer
+		^CDrain HTTPServer
+		Drain AdminHTTPServer
+		Stop HTTPServer
+		Finished serving HTTPServer
+		Stopped HTTPServer
+		Stop DBConn
+		Stopped DBConn
+		Stop AdminHTTPServer
+		Finished serving AdminHTTPServer
+		Stopped AdminHTTPServer
+	*/
 }
@@ -12,13 +12,15 @@ import (
 	"net/http"
 
 	"github.com/vivid-money/article-golang-di/pkg/components"
+	"github.com/vivid-money/article-golang-di/pkg/fakedb"
+	"github.com/vivid-money/article-golang-di/pkg/manual"
 )
 
 // Поскольку wire не поддерживает lifecycle (точнее, поддерживает только Cleanup-функции), а мы не хотим
 // делать вызовы компонентов в нужном порядке руками, то придётся написать специальные врапперы для конструкторов,
 // которые при этом будут при создании компонента начинать работу и возвращать cleanup-функцию для его остановки.
 func NewDBConn(ctx context.Context, logger components.Logger) (*components.DBConn, func(), error) {
-	conn := components.NewDBConn(logger)
+	conn := components.NewDBConn(logger, fakedb.Config())
 	if err := conn.Connect(ctx); err != nil {
 		return nil, nil, fmt.Errorf("can't connect to db: %w", err)
 	}
@@ -37,7 +39,8 @@ func NewHTTPServer(
 ) (*components.HTTPServer, func()) {
 	srv := components.NewHTTPServer(logger, conn)
 	go func() {
-		if err := srv.Serve(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		// Паника в сервере превратится в ошибку, а closer аккуратно остановит приложение.
+		if err := manual.CatchPanic(srv.Serve)(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			logger.Print("Error serving http: ", err)
 		}
 		closer()